@@ -0,0 +1,133 @@
+package try
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// All performs all the given operations concurrently, each retried independently.
+// See AllWithOptions.
+func All(ctx context.Context, ops []func(ctx context.Context) error, retryOptions ...RetryOption) error {
+	option := NewOptions(retryOptions...)
+	return AllWithOptions(ctx, ops, option)
+}
+
+// AllWithOptions performs all the given operations concurrently, each retried independently based on the options.
+// The number of operations running at the same time can be limited using WithParallelism.
+// Return the joined errors of the operations that still failed, each annotated with the index of its operation.
+func AllWithOptions(ctx context.Context, ops []func(ctx context.Context) error, options Options) error {
+	options.context = ctx
+	errs := make([]error, len(ops))
+	parallel(len(ops), options.parallelism, func(i int) {
+		err := DoWithOptions(func() error {
+			return ops[i](ctx)
+		}, options)
+		if err != nil {
+			errs[i] = fmt.Errorf("operation %d: %w", i, err)
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// parallel calls fn for every index in [0, n), running at most limit calls at the same time.
+// A limit <= 0 means no limit.
+func parallel(n int, limit int, fn func(i int)) {
+	if limit <= 0 || limit > n {
+		limit = n
+	}
+	sem := make(chan struct{}, limit)
+	wg := sync.WaitGroup{}
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// GetAll fetches all the given keys concurrently, each retried independently.
+// See GetAllWithOptions.
+func GetAll[K comparable, V any](ctx context.Context, keys []K, fetch func(ctx context.Context, key K) (V, error), retryOptions ...RetryOption) (map[K]V, map[K]error) {
+	option := NewOptions(retryOptions...)
+	return GetAllWithOptions(ctx, keys, fetch, option)
+}
+
+// GetAllWithOptions fetches all the given keys concurrently, each retried independently based on the options.
+// The number of keys fetched at the same time can be limited using WithParallelism.
+// Return the values of the keys that succeeded, and the errors of the keys that still failed.
+func GetAllWithOptions[K comparable, V any](ctx context.Context, keys []K, fetch func(ctx context.Context, key K) (V, error), options Options) (map[K]V, map[K]error) {
+	options.context = ctx
+	values := make([]V, len(keys))
+	errs := make([]error, len(keys))
+	parallel(len(keys), options.parallelism, func(i int) {
+		values[i], errs[i] = GetWithOptions(func() (V, error) {
+			return fetch(ctx, keys[i])
+		}, options)
+	})
+
+	results := make(map[K]V, len(keys))
+	failures := make(map[K]error)
+	for i, key := range keys {
+		if errs[i] != nil {
+			failures[key] = errs[i]
+			continue
+		}
+		results[key] = values[i]
+	}
+	return results, failures
+}
+
+// Each applies the operation to every item concurrently, each retried independently.
+// See EachWithOptions.
+func Each[T any](ctx context.Context, items []T, fn func(ctx context.Context, item T) error, retryOptions ...RetryOption) map[int]error {
+	option := NewOptions(retryOptions...)
+	return EachWithOptions(ctx, items, fn, option)
+}
+
+// EachWithOptions applies the operation to every item concurrently, each retried independently based on the options.
+// The number of items processed at the same time can be limited using WithParallelism,
+// and the retries can share a budget using WithBudget or ContextWithBudget.
+// Return the errors of the items that still failed, keyed by the index of the item.
+func EachWithOptions[T any](ctx context.Context, items []T, fn func(ctx context.Context, item T) error, options Options) map[int]error {
+	_, failures := MapEachWithOptions(ctx, items, func(ctx context.Context, item T) (struct{}, error) {
+		return struct{}{}, fn(ctx, item)
+	}, options)
+	return failures
+}
+
+// MapEach applies the operation to every item concurrently, each retried independently, and return the results.
+// See MapEachWithOptions.
+func MapEach[T any, R any](ctx context.Context, items []T, fn func(ctx context.Context, item T) (R, error), retryOptions ...RetryOption) ([]R, map[int]error) {
+	option := NewOptions(retryOptions...)
+	return MapEachWithOptions(ctx, items, fn, option)
+}
+
+// MapEachWithOptions applies the operation to every item concurrently, each retried independently based on the options.
+// See EachWithOptions.
+// Return the results in the order of the items, with the zero value for the items that still failed,
+// and the errors of the items that still failed, keyed by the index of the item.
+func MapEachWithOptions[T any, R any](ctx context.Context, items []T, fn func(ctx context.Context, item T) (R, error), options Options) ([]R, map[int]error) {
+	results := make([]R, len(items))
+	errs := make([]error, len(items))
+	parallel(len(items), options.parallelism, func(i int) {
+		results[i], errs[i] = GetCtxWithOptions(ctx, func(ctx context.Context) (R, error) {
+			return fn(ctx, items[i])
+		}, options)
+	})
+
+	failures := make(map[int]error)
+	for i, err := range errs {
+		if err != nil {
+			failures[i] = err
+		}
+	}
+	return results, failures
+}
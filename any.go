@@ -0,0 +1,73 @@
+package try
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNoOperation is returned by DoAny and GetAny when there is no operation to perform.
+var ErrNoOperation = errors.New("no operation")
+
+// DoAny performs the given alternative operations concurrently, each retried independently, until one succeeds.
+// See GetAnyWithOptions.
+func DoAny(ctx context.Context, ops []func(ctx context.Context) error, retryOptions ...RetryOption) error {
+	option := NewOptions(retryOptions...)
+	return DoAnyWithOptions(ctx, ops, option)
+}
+
+// DoAnyWithOptions performs the given alternative operations concurrently, each retried independently, until one succeeds.
+// See GetAnyWithOptions.
+func DoAnyWithOptions(ctx context.Context, ops []func(ctx context.Context) error, options Options) error {
+	wrapped := make([]func(ctx context.Context) (struct{}, error), len(ops))
+	for i, op := range ops {
+		wrapped[i] = func(ctx context.Context) (struct{}, error) {
+			return struct{}{}, op(ctx)
+		}
+	}
+	_, err := GetAnyWithOptions(ctx, wrapped, options)
+	return err
+}
+
+// GetAny performs the given alternative operations concurrently, each retried independently,
+// and return the result of the first one that succeeds.
+// See GetAnyWithOptions.
+func GetAny[T any](ctx context.Context, ops []func(ctx context.Context) (T, error), retryOptions ...RetryOption) (T, error) {
+	option := NewOptions(retryOptions...)
+	return GetAnyWithOptions(ctx, ops, option)
+}
+
+// GetAnyWithOptions performs the given alternative operations concurrently, for example, the same request to multiple replicas,
+// each retried independently based on the options, and return the result of the first one that succeeds.
+// The context of the other operations is canceled once one succeeds, they are not waited for.
+// If all of them still failed, return the joined errors of the operations, each annotated with the index of its operation.
+func GetAnyWithOptions[T any](ctx context.Context, ops []func(ctx context.Context) (T, error), options Options) (T, error) {
+	var empty T
+	if len(ops) == 0 {
+		return empty, ErrNoOperation
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type result struct {
+		i   int
+		v   T
+		err error
+	}
+	results := make(chan result, len(ops))
+	for i, op := range ops {
+		go func() {
+			v, err := GetCtxWithOptions(ctx, op, options)
+			results <- result{i: i, v: v, err: err}
+		}()
+	}
+
+	errs := make([]error, len(ops))
+	for range ops {
+		r := <-results
+		if r.err == nil {
+			return r.v, nil
+		}
+		errs[r.i] = fmt.Errorf("operation %d: %w", r.i, r.err)
+	}
+	return empty, errors.Join(errs...)
+}
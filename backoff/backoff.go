@@ -1,14 +1,42 @@
 package backoff
 
 import (
+	"errors"
+	"fmt"
 	"math"
-	"math/rand"
+	"strings"
+	"sync"
 	"time"
 )
 
 // Strategy is a function that calculates the backoff.
+// Returning a negative duration, such as Stop, signals to give up instead of retrying.
 type Strategy func(err error, i int) time.Duration
 
+// Next return the backoff, implementing StatefulStrategy.
+func (s Strategy) Next(err error, i int) time.Duration {
+	return s(err, i)
+}
+
+// Reset does nothing, as a Strategy has no state.
+func (s Strategy) Reset() {}
+
+// StatefulStrategy is a backoff strategy that keeps state across calls,
+// such as the previous backoff, and can be reset when the operation succeeded.
+// A Strategy is a StatefulStrategy without state.
+type StatefulStrategy interface {
+	// Next return the backoff before the ith retry of the failed operation.
+	Next(err error, i int) time.Duration
+	// Reset the state of the strategy, called when the operation succeeded.
+	Reset()
+}
+
+// Stop is returned by a Strategy to stop retrying.
+const Stop time.Duration = -1
+
+// Exhausted is returned by a schedule-based Strategy to stop retrying when its schedule has no more delays.
+const Exhausted time.Duration = -2
+
 // NewFixedBackoff return a BackoffStrategy that backoff at a fixed rate.
 func NewFixedBackoff(backoff time.Duration) Strategy {
 	return func(_ error, _ int) time.Duration {
@@ -17,55 +45,193 @@ func NewFixedBackoff(backoff time.Duration) Strategy {
 }
 
 // NewRandomBackoff return a NewFixedBackoff with added random jitter.
-func NewRandomBackoff(minBackoff time.Duration, jitter time.Duration) Strategy {
-	return NewBackoffWithJitter(NewFixedBackoff(minBackoff), jitter)
+func NewRandomBackoff(minBackoff time.Duration, jitter time.Duration, opts ...Option) Strategy {
+	return NewBackoffWithJitter(NewFixedBackoff(minBackoff), jitter, opts...)
 }
 
 // NewBackoffWithJitter add random jitter to existing BackoffStrategy.
+// A jitter of 0 adds nothing.
 // The jitter is always added, which may not respect configuration of existing BackoffStrategy,
 // for example, ExponentialBackoff max wait time may > maximumBackoff because of the jitter.
 //
 // This construct is intended to easily add jitter to user defined backoff Strategy.
 // For built-in Strategy, you better use the RandomBackoff variant of it.
-func NewBackoffWithJitter(backoff Strategy, jitter time.Duration) Strategy {
+func NewBackoffWithJitter(backoff Strategy, jitter time.Duration, opts ...Option) Strategy {
+	c := newConfig(opts)
+	return func(err error, i int) time.Duration {
+		d := backoff(err, i)
+		if d < 0 {
+			return d
+		}
+		return addDuration(d, c.jitter(jitter))
+	}
+}
+
+// NewBackoffWithRelativeJitter add random jitter proportional to the backoff of an existing BackoffStrategy,
+// for example, a fraction of 0.2 adds up to 20% of each backoff.
+// A fraction of 0 adds nothing.
+func NewBackoffWithRelativeJitter(backoff Strategy, fraction float64, opts ...Option) Strategy {
+	c := newConfig(opts)
 	return func(err error, i int) time.Duration {
-		return backoff(err, i) + time.Duration(rand.Int63n(int64(jitter)))
+		d := backoff(err, i)
+		if d < 0 {
+			return d
+		}
+		return addDuration(d, c.jitter(time.Duration(float64(d)*fraction)))
 	}
 }
 
 // NewExponentialBackoff return a BackoffStrategy that backoff at an exponential rate.
-func NewExponentialBackoff(initialBackoff time.Duration, multiplier int, maximumBackoff time.Duration) Strategy {
+// The multiplier can be fractional, for example, 1.5.
+// The backoff never overflows, it stops growing at the maximumBackoff, or at the maximum time.Duration if maximumBackoff is 0.
+func NewExponentialBackoff(initialBackoff time.Duration, multiplier float64, maximumBackoff time.Duration) Strategy {
+	return func(_ error, i int) time.Duration {
+		return exponentialBackoff(initialBackoff, multiplier, maximumBackoff, i)
+	}
+}
+
+// NewExponentialRandomBackoff return a ExponentialBackoff with added random jitter, and respect the maximum backoff.
+// Once the maximum is reached, the jitter is subtracted from it instead.
+func NewExponentialRandomBackoff(initialBackoff time.Duration, multiplier float64, maximumBackoff time.Duration, jitter time.Duration, opts ...Option) Strategy {
+	c := newConfig(opts)
 	return func(_ error, i int) time.Duration {
-		exponential := math.Pow(float64(multiplier), float64(i-1))
-		backoff := initialBackoff * time.Duration(exponential)
+		jitter := c.jitter(jitter)
+		backoff := exponentialBackoff(initialBackoff, multiplier, maximumBackoff, i)
 		if maximumBackoff == 0 {
 			return backoff
 		}
-		return min(backoff, maximumBackoff)
+		return randomBackoff(backoff, maximumBackoff, jitter)
 	}
 }
 
-// NewExponentialRandomBackoff return a ExponentialBackoff with added random jitter, and respect the maximum backoff.
-func NewExponentialRandomBackoff(initialBackoff time.Duration, multiplier int, maximumBackoff time.Duration, jitter time.Duration) Strategy {
+// randomBackoff add the jitter to the backoff, without exceeding the maximumBackoff.
+// Once the maximum is reached, the jitter is subtracted from it instead, never going below 0.
+func randomBackoff(backoff time.Duration, maximumBackoff time.Duration, jitter time.Duration) time.Duration {
+	if backoff >= maximumBackoff {
+		return max(maximumBackoff-jitter, 0)
+	}
+	if backoff >= maximumBackoff-jitter {
+		return maximumBackoff
+	}
+	return backoff + jitter
+}
+
+// addDuration return a + b for a non-negative b, stopping at the maximum time.Duration.
+func addDuration(a time.Duration, b time.Duration) time.Duration {
+	if a > math.MaxInt64-b {
+		return math.MaxInt64
+	}
+	return a + b
+}
+
+// floatDuration convert a non-negative number of nanoseconds to a time.Duration, stopping at the maximum time.Duration.
+func floatDuration(f float64) time.Duration {
+	if f >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(f)
+}
+
+// exponentialBackoff return initialBackoff * multiplier^(i-1), limited to maximumBackoff,
+// or to the maximum time.Duration if maximumBackoff is 0.
+func exponentialBackoff(initialBackoff time.Duration, multiplier float64, maximumBackoff time.Duration, i int) time.Duration {
+	limit := time.Duration(math.MaxInt64)
+	if maximumBackoff > 0 {
+		limit = maximumBackoff
+	}
+	backoff := float64(initialBackoff) * math.Pow(multiplier, float64(i-1))
+	if backoff >= float64(limit) {
+		return limit
+	}
+	return time.Duration(backoff)
+}
+
+// NewIncrementalBackoff return a BackoffStrategy that increment backoff every retry.
+// The backoff never overflows, it stops growing at the maximumBackoff, or at the maximum time.Duration if maximumBackoff is 0.
+func NewIncrementalBackoff(initialBackoff time.Duration, incremental time.Duration, maximumBackoff time.Duration) Strategy {
 	return func(_ error, i int) time.Duration {
-		exponential := math.Pow(float64(multiplier), float64(i-1))
-		jitter := time.Duration(rand.Int63n(int64(jitter)))
-		backoff := initialBackoff * time.Duration(exponential)
+		return incrementalBackoff(initialBackoff, incremental, maximumBackoff, i)
+	}
+}
+
+// NewIncrementalRandomBackoff return an IncrementalBackoff with added random jitter, and respect the maximum backoff.
+func NewIncrementalRandomBackoff(initialBackoff time.Duration, incremental time.Duration, maximumBackoff time.Duration, jitter time.Duration, opts ...Option) Strategy {
+	c := newConfig(opts)
+	return func(_ error, i int) time.Duration {
+		jitter := c.jitter(jitter)
+		backoff := incrementalBackoff(initialBackoff, incremental, maximumBackoff, i)
 		if maximumBackoff == 0 {
 			return backoff
 		}
-		if backoff >= maximumBackoff {
-			return max(backoff - jitter + 0)
+		return randomBackoff(backoff, maximumBackoff, jitter)
+	}
+}
+
+// incrementalBackoff return initialBackoff + incremental * (i-1), limited to [0, maximumBackoff],
+// or to the maximum time.Duration if maximumBackoff is 0.
+func incrementalBackoff(initialBackoff time.Duration, incremental time.Duration, maximumBackoff time.Duration, i int) time.Duration {
+	limit := time.Duration(math.MaxInt64)
+	if maximumBackoff > 0 {
+		limit = maximumBackoff
+	}
+	backoff := float64(initialBackoff) + float64(incremental)*float64(i-1)
+	if backoff >= float64(limit) {
+		return limit
+	}
+	return time.Duration(max(backoff, 0))
+}
+
+// NewLoadAwareBackoff scale the existing BackoffStrategy based on the current load reported by the probe.
+// The probe returns a load factor where 1 means the process is running at its nominal capacity,
+// for example, runtime.NumGoroutine() divided by the expected number of goroutines.
+// Delays are multiplied by the load factor, values below 1 or NaN do not shorten the backoff.
+// The backoff stops growing at the maximum time.Duration.
+func NewLoadAwareBackoff(backoff Strategy, probe func() float64) Strategy {
+	return func(err error, i int) time.Duration {
+		d := backoff(err, i)
+		if d < 0 {
+			return d
+		}
+		load := probe()
+		if !(load > 1) {
+			return d
 		}
-		return min(backoff+jitter, maximumBackoff)
+		return floatDuration(float64(d) * load)
 	}
 }
 
-// NewIncrementalBackoff return a BackoffStrategy that increment backoff every retry.
-func NewIncrementalBackoff(initialBackoff time.Duration, incremental time.Duration, maximumBackoff time.Duration) Strategy {
+// NewScheduleBackoff return a BackoffStrategy that wait for the nth duration before the nth retry.
+// Once all durations are used, it keeps waiting for the last one.
+// Use NewFiniteScheduleBackoff to stop retrying at the end of the schedule instead.
+func NewScheduleBackoff(durations ...time.Duration) Strategy {
 	return func(_ error, i int) time.Duration {
-		inc := incremental * time.Duration(i-1)
-		backoff := initialBackoff + inc
+		if len(durations) == 0 {
+			return 0
+		}
+		return durations[min(i, len(durations))-1]
+	}
+}
+
+// NewFiniteScheduleBackoff return a BackoffStrategy that wait for the nth duration before the nth retry.
+// Once all durations are used, it returns Exhausted, so the schedule also limits the number of retries.
+func NewFiniteScheduleBackoff(durations ...time.Duration) Strategy {
+	return func(_ error, i int) time.Duration {
+		if i > len(durations) {
+			return Exhausted
+		}
+		return durations[i-1]
+	}
+}
+
+// NewHarmonicBackoff return a BackoffStrategy that backoff at a harmonic rate,
+// the nth retry waits initialBackoff * (1 + 1/2 + ... + 1/n), which grows slower and slower.
+func NewHarmonicBackoff(initialBackoff time.Duration, maximumBackoff time.Duration) Strategy {
+	return func(_ error, i int) time.Duration {
+		harmonic := 0.0
+		for k := 1; k <= i; k++ {
+			harmonic += 1 / float64(k)
+		}
+		backoff := floatDuration(float64(initialBackoff) * harmonic)
 		if maximumBackoff == 0 {
 			return backoff
 		}
@@ -73,18 +239,267 @@ func NewIncrementalBackoff(initialBackoff time.Duration, incremental time.Durati
 	}
 }
 
-// NewIncrementalRandomBackoff return an IncrementalBackoff with added random jitter, and respect the maximum backoff.
-func NewIncrementalRandomBackoff(initialBackoff time.Duration, incremental time.Duration, maximumBackoff time.Duration, jitter time.Duration) Strategy {
+// NewLogarithmicBackoff return a BackoffStrategy that backoff at a logarithmic rate,
+// the nth retry waits initialBackoff * (1 + ln(n)), which grows slower and slower.
+func NewLogarithmicBackoff(initialBackoff time.Duration, maximumBackoff time.Duration) Strategy {
 	return func(_ error, i int) time.Duration {
-		inc := incremental * time.Duration(i-1)
-		jitter := time.Duration(rand.Int63n(int64(jitter)))
-		backoff := initialBackoff + inc
+		backoff := floatDuration(float64(initialBackoff) * (1 + math.Log(float64(i))))
 		if maximumBackoff == 0 {
 			return backoff
 		}
-		if backoff >= maximumBackoff {
-			return max(backoff - jitter + 0)
+		return min(backoff, maximumBackoff)
+	}
+}
+
+// DecorrelatedJitterBackoff is a StatefulStrategy implementing the "decorrelated jitter" algorithm,
+// where each backoff is random between the base and 3 times the previous backoff, limited by the maximum.
+// It spreads retries of concurrent callers better than adding a fixed range jitter.
+// The previous backoff is kept until Reset, and it is safe for concurrent use.
+type DecorrelatedJitterBackoff struct {
+	base           time.Duration
+	maximumBackoff time.Duration
+	config         config
+	mu             sync.Mutex
+	prev           time.Duration
+}
+
+// NewDecorrelatedJitterBackoff return a DecorrelatedJitterBackoff.
+// A maximumBackoff of 0 means no limit.
+func NewDecorrelatedJitterBackoff(base time.Duration, maximumBackoff time.Duration, opts ...Option) *DecorrelatedJitterBackoff {
+	return &DecorrelatedJitterBackoff{
+		base:           base,
+		maximumBackoff: maximumBackoff,
+		config:         newConfig(opts),
+	}
+}
+
+// Next implements StatefulStrategy.
+func (b *DecorrelatedJitterBackoff) Next(_ error, _ int) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	prev := max(b.prev, b.base)
+	backoff := b.base
+	if upper := floatDuration(float64(prev) * 3); upper > b.base {
+		backoff += b.config.jitter(upper - b.base)
+	}
+	if b.maximumBackoff > 0 {
+		backoff = min(backoff, b.maximumBackoff)
+	}
+	b.prev = backoff
+	return backoff
+}
+
+// Reset implements StatefulStrategy, the next backoff starts from the base again.
+func (b *DecorrelatedJitterBackoff) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.prev = 0
+}
+
+// AdaptiveBackoff is a StatefulStrategy that tunes the backoff across calls using AIMD:
+// the backoff is multiplied on every failure, and decreased by a fixed step on every success (Reset),
+// so the pressure on a flapping dependency adapts to how often it fails.
+// It is safe for concurrent use.
+type AdaptiveBackoff struct {
+	minimumBackoff time.Duration
+	maximumBackoff time.Duration
+	multiplier     float64
+	decrease       time.Duration
+	mu             sync.Mutex
+	current        time.Duration
+}
+
+// NewAdaptiveBackoff return an AdaptiveBackoff starting at minimumBackoff,
+// multiplied by multiplier on every failure up to maximumBackoff, and decreased by decrease on every success.
+// A maximumBackoff of 0 means no limit.
+func NewAdaptiveBackoff(minimumBackoff time.Duration, maximumBackoff time.Duration, multiplier float64, decrease time.Duration) *AdaptiveBackoff {
+	return &AdaptiveBackoff{
+		minimumBackoff: minimumBackoff,
+		maximumBackoff: maximumBackoff,
+		multiplier:     multiplier,
+		decrease:       decrease,
+		current:        minimumBackoff,
+	}
+}
+
+// Next implements StatefulStrategy, return the current backoff and increase it multiplicatively.
+func (b *AdaptiveBackoff) Next(_ error, _ int) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	backoff := b.current
+	b.current = exponentialBackoff(max(b.current, 1), b.multiplier, b.maximumBackoff, 2)
+	return backoff
+}
+
+// Reset implements StatefulStrategy, decrease the backoff additively, down to the minimum.
+func (b *AdaptiveBackoff) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.current = max(b.current-b.decrease, b.minimumBackoff)
+}
+
+// Current return the backoff the next failure will wait.
+func (b *AdaptiveBackoff) Current() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.current
+}
+
+// NewExponentialFullJitterBackoff return an ExponentialBackoff where each backoff is random between 0 and the exponential backoff,
+// which implements the "full jitter" algorithm and never exceeds the maximum.
+func NewExponentialFullJitterBackoff(initialBackoff time.Duration, multiplier float64, maximumBackoff time.Duration, opts ...Option) Strategy {
+	c := newConfig(opts)
+	return func(_ error, i int) time.Duration {
+		backoff := exponentialBackoff(initialBackoff, multiplier, maximumBackoff, i)
+		return c.upTo(backoff)
+	}
+}
+
+// NewExponentialEqualJitterBackoff return an ExponentialBackoff where each backoff is half the exponential backoff plus a random value up to the other half,
+// which implements the "equal jitter" algorithm and never exceeds the maximum.
+func NewExponentialEqualJitterBackoff(initialBackoff time.Duration, multiplier float64, maximumBackoff time.Duration, opts ...Option) Strategy {
+	c := newConfig(opts)
+	return func(_ error, i int) time.Duration {
+		half := exponentialBackoff(initialBackoff, multiplier, maximumBackoff, i) / 2
+		return half + c.upTo(half)
+	}
+}
+
+// DeadlineStrategy is a backoff strategy that also receives the time and attempts left in the retry.
+// The remaining is the time left until the deadline, or 0 if there is no deadline,
+// and attemptsLeft is the number of attempts still allowed, or 0 if unlimited.
+type DeadlineStrategy func(err error, i int, remaining time.Duration, attemptsLeft int) time.Duration
+
+// NewDeadlineSpread return a DeadlineStrategy that divide the remaining time evenly
+// between the remaining attempts and the backoff before each of them,
+// so all the configured attempts fit inside the deadline.
+// The fallback is used when there is no deadline or the attempts are unlimited.
+func NewDeadlineSpread(fallback Strategy) DeadlineStrategy {
+	return func(err error, i int, remaining time.Duration, attemptsLeft int) time.Duration {
+		if remaining <= 0 || attemptsLeft <= 0 {
+			return fallback(err, i)
+		}
+		return remaining / time.Duration(attemptsLeft+1)
+	}
+}
+
+// Cap limit the backoff of the existing BackoffStrategy to the maximum.
+// Negative durations signaling to stop retrying are kept, and a maximum of 0 means no maximum.
+// It panics if the maximum is negative, as capped delays would be read as Stop.
+func Cap(backoff Strategy, maximumBackoff time.Duration) Strategy {
+	if maximumBackoff < 0 {
+		panic(fmt.Sprintf("backoff: negative maximum backoff %s", maximumBackoff))
+	}
+	if maximumBackoff == 0 {
+		return backoff
+	}
+	return func(err error, i int) time.Duration {
+		return min(backoff(err, i), maximumBackoff)
+	}
+}
+
+// Floor raise the backoff of the existing BackoffStrategy to at least the minimum.
+// Negative durations signaling to stop retrying are kept.
+func Floor(backoff Strategy, minimumBackoff time.Duration) Strategy {
+	return func(err error, i int) time.Duration {
+		d := backoff(err, i)
+		if d < 0 {
+			return d
+		}
+		return max(d, minimumBackoff)
+	}
+}
+
+// Scale multiply the backoff of the existing BackoffStrategy by the factor.
+// Negative durations signaling to stop retrying are kept.
+// The backoff stops growing at the maximum time.Duration.
+// It panics if the factor is negative or NaN, as scaled delays would be read as Stop.
+func Scale(backoff Strategy, factor float64) Strategy {
+	if !(factor >= 0) {
+		panic(fmt.Sprintf("backoff: invalid scale factor %g", factor))
+	}
+	return func(err error, i int) time.Duration {
+		d := backoff(err, i)
+		if d < 0 {
+			return d
 		}
-		return min(backoff+jitter, maximumBackoff)
+		return floatDuration(float64(d) * factor)
+	}
+}
+
+// Case pairs an error matcher with the BackoffStrategy to use for the errors it matches.
+// See Switch.
+type Case struct {
+	Match    func(err error) bool
+	Strategy Strategy
+}
+
+// When return a Case using the strategy for the errors matched by match.
+// A try.ErrorMatcher can be used as match.
+func When(match func(err error) bool, strategy Strategy) Case {
+	return Case{Match: match, Strategy: strategy}
+}
+
+// Switch return a BackoffStrategy that delegates to the strategy of the first Case matching the error,
+// or to the fallback if no Case matches,
+// so different error classes can have different backoff, for example, rate limit errors can wait longer.
+func Switch(fallback Strategy, cases ...Case) Strategy {
+	return func(err error, i int) time.Duration {
+		for _, c := range cases {
+			if c.Match(err) {
+				return c.Strategy(err, i)
+			}
+		}
+		return fallback(err, i)
+	}
+}
+
+// RetryAfterHint is implemented by errors carrying a server-provided backoff,
+// such as the Retry-After header of an HTTP 429 response, or the RetryInfo of a gRPC status.
+type RetryAfterHint interface {
+	RetryAfter() time.Duration
+}
+
+// NewHintAwareBackoff return a BackoffStrategy that waits for the duration hinted by the error,
+// if it implements RetryAfterHint (checked using errors.As), falling back to the given strategy otherwise.
+// Negative hints are ignored.
+func NewHintAwareBackoff(fallback Strategy) Strategy {
+	return func(err error, i int) time.Duration {
+		var hint RetryAfterHint
+		if errors.As(err, &hint) {
+			if d := hint.RetryAfter(); d >= 0 {
+				return d
+			}
+		}
+		return fallback(err, i)
+	}
+}
+
+// Schedule return the backoff the strategy produces before each of the first n retries, without waiting.
+// The schedule ends early if the strategy signals to stop retrying.
+// The strategy is called with a nil error.
+func Schedule(strategy Strategy, n int) []time.Duration {
+	schedule := make([]time.Duration, 0, n)
+	for i := 1; i <= n; i++ {
+		d := strategy(nil, i)
+		if d < 0 {
+			break
+		}
+		schedule = append(schedule, d)
+	}
+	return schedule
+}
+
+// Describe return a human-readable description of the Schedule of the first n retries, such as "200ms, 400ms, 800ms".
+// If the strategy signals to stop retrying within the first n retries, the description ends with "stop".
+func Describe(strategy Strategy, n int) string {
+	schedule := Schedule(strategy, n)
+	parts := make([]string, 0, len(schedule)+1)
+	for _, d := range schedule {
+		parts = append(parts, d.String())
+	}
+	if len(schedule) < n {
+		parts = append(parts, "stop")
 	}
+	return strings.Join(parts, ", ")
 }
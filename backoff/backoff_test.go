@@ -0,0 +1,216 @@
+package backoff
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestLoadAwareBackoff(t *testing.T) {
+	load := 0.5
+	strategy := NewLoadAwareBackoff(NewFixedBackoff(100*time.Millisecond), func() float64 {
+		return load
+	})
+	assert.Equal(t, 100*time.Millisecond, strategy(nil, 1))
+	load = 2.5
+	assert.Equal(t, 250*time.Millisecond, strategy(nil, 2))
+	load = math.NaN()
+	assert.Equal(t, 100*time.Millisecond, strategy(nil, 3))
+	load = math.Inf(1)
+	assert.Equal(t, time.Duration(math.MaxInt64), strategy(nil, 4))
+	load = 1e30
+	assert.Equal(t, time.Duration(math.MaxInt64), strategy(nil, 5))
+}
+
+func TestHarmonicBackoff(t *testing.T) {
+	strategy := NewHarmonicBackoff(120*time.Millisecond, 240*time.Millisecond)
+	assert.Equal(t, 120*time.Millisecond, strategy(nil, 1))
+	assert.Equal(t, 180*time.Millisecond, strategy(nil, 2))
+	assert.Equal(t, 220*time.Millisecond, strategy(nil, 3))
+	assert.Equal(t, 240*time.Millisecond, strategy(nil, 4))
+}
+
+func TestLogarithmicBackoff(t *testing.T) {
+	strategy := NewLogarithmicBackoff(100*time.Millisecond, 0)
+	assert.Equal(t, 100*time.Millisecond, strategy(nil, 1))
+	assert.InDelta(t, float64(169*time.Millisecond), float64(strategy(nil, 2)), float64(time.Millisecond))
+	assert.Less(t, strategy(nil, 10)-strategy(nil, 9), strategy(nil, 2)-strategy(nil, 1))
+}
+
+func TestDecorrelatedJitterBackoff(t *testing.T) {
+	strategy := NewDecorrelatedJitterBackoff(10*time.Millisecond, 100*time.Millisecond)
+	prev := 10 * time.Millisecond
+	for i := 1; i <= 20; i++ {
+		d := strategy.Next(nil, i)
+		assert.GreaterOrEqual(t, d, 10*time.Millisecond)
+		assert.LessOrEqual(t, d, min(prev*3, 100*time.Millisecond))
+		prev = d
+	}
+	strategy.Reset()
+	assert.Less(t, strategy.Next(nil, 1), 30*time.Millisecond)
+}
+
+func TestExponentialJitterBackoff(t *testing.T) {
+	full := NewExponentialFullJitterBackoff(100*time.Millisecond, 2, 300*time.Millisecond)
+	equal := NewExponentialEqualJitterBackoff(100*time.Millisecond, 2, 300*time.Millisecond)
+	for i := 1; i <= 5; i++ {
+		exponential := min(100*time.Millisecond<<(i-1), 300*time.Millisecond)
+		d := full(nil, i)
+		assert.GreaterOrEqual(t, d, time.Duration(0))
+		assert.LessOrEqual(t, d, exponential)
+		d = equal(nil, i)
+		assert.GreaterOrEqual(t, d, exponential/2)
+		assert.LessOrEqual(t, d, exponential)
+	}
+}
+
+func TestScheduleBackoff(t *testing.T) {
+	strategy := NewScheduleBackoff(time.Second, 5*time.Second, 30*time.Second)
+	assert.Equal(t, time.Second, strategy(nil, 1))
+	assert.Equal(t, 5*time.Second, strategy(nil, 2))
+	assert.Equal(t, 30*time.Second, strategy(nil, 3))
+	assert.Equal(t, 30*time.Second, strategy(nil, 10))
+
+	finite := NewFiniteScheduleBackoff(time.Second)
+	assert.Equal(t, time.Second, finite(nil, 1))
+	assert.Equal(t, Exhausted, finite(nil, 2))
+}
+
+func TestBackoffDecorators(t *testing.T) {
+	strategy := NewIncrementalBackoff(100*time.Millisecond, 100*time.Millisecond, 0)
+	capped := Cap(strategy, 250*time.Millisecond)
+	assert.Equal(t, 200*time.Millisecond, capped(nil, 2))
+	assert.Equal(t, 250*time.Millisecond, capped(nil, 3))
+
+	assert.Equal(t, 300*time.Millisecond, Cap(strategy, 0)(nil, 3))
+	assert.Panics(t, func() {
+		Cap(strategy, -1)
+	})
+
+	floored := Floor(strategy, 150*time.Millisecond)
+	assert.Equal(t, 150*time.Millisecond, floored(nil, 1))
+	assert.Equal(t, 200*time.Millisecond, floored(nil, 2))
+
+	scaled := Scale(strategy, 0.5)
+	assert.Equal(t, 50*time.Millisecond, scaled(nil, 1))
+	assert.Equal(t, time.Duration(math.MaxInt64), Scale(NewFixedBackoff(time.Hour), 1e10)(nil, 1))
+	assert.Panics(t, func() {
+		Scale(strategy, -1)
+	})
+
+	stop := NewFiniteScheduleBackoff()
+	assert.Equal(t, Exhausted, Cap(Floor(Scale(stop, 2), time.Second), time.Minute)(nil, 1))
+}
+
+func TestExponentialBackoffFloatMultiplierAndOverflow(t *testing.T) {
+	strategy := NewExponentialBackoff(100*time.Millisecond, 1.5, 0)
+	assert.Equal(t, 100*time.Millisecond, strategy(nil, 1))
+	assert.Equal(t, 150*time.Millisecond, strategy(nil, 2))
+	assert.Equal(t, 225*time.Millisecond, strategy(nil, 3))
+	assert.Equal(t, time.Duration(math.MaxInt64), strategy(nil, 1000))
+
+	capped := NewExponentialBackoff(time.Second, 2, time.Minute)
+	assert.Equal(t, time.Minute, capped(nil, 100))
+	assert.Equal(t, time.Minute, capped(nil, 10000))
+
+	random := NewExponentialRandomBackoff(time.Second, 2, time.Minute, time.Second)
+	d := random(nil, 100)
+	assert.LessOrEqual(t, d, time.Minute)
+	assert.Greater(t, d, time.Minute-time.Second)
+
+	full := NewExponentialFullJitterBackoff(time.Second, 2, 0)
+	assert.GreaterOrEqual(t, full(nil, 1000), time.Duration(0))
+}
+
+func TestStrategiesNeverStop(t *testing.T) {
+	huge := time.Duration(math.MaxInt64 / 2)
+	strategies := map[string]Strategy{
+		"incremental random":    NewIncrementalRandomBackoff(time.Second, 0, time.Second, 5*time.Second),
+		"incremental overflow":  NewIncrementalBackoff(time.Second, huge, 0),
+		"exponential random":    NewExponentialRandomBackoff(time.Second, 2, time.Second, 5*time.Second),
+		"exponential jitter":    NewBackoffWithJitter(NewExponentialBackoff(time.Second, 2, 0), time.Hour),
+		"relative jitter":       NewBackoffWithRelativeJitter(NewFixedBackoff(huge*2), 1),
+		"harmonic overflow":     NewHarmonicBackoff(huge, 0),
+		"logarithmic overflow":  NewLogarithmicBackoff(huge, 0),
+		"decorrelated overflow": NewDecorrelatedJitterBackoff(huge, 0).Next,
+		"full jitter":           NewExponentialFullJitterBackoff(time.Second, 2, 0),
+		"equal jitter":          NewExponentialEqualJitterBackoff(time.Second, 2, 0),
+	}
+	for name, strategy := range strategies {
+		for _, i := range []int{1, 2, 3, 10, 100, 1000} {
+			for range 100 {
+				assert.GreaterOrEqual(t, strategy(nil, i), time.Duration(0), "%s, retry %d", name, i)
+			}
+		}
+	}
+	assert.Equal(t, time.Duration(math.MaxInt64), NewIncrementalBackoff(time.Second, huge, 0)(nil, 4))
+}
+
+func TestWithRand(t *testing.T) {
+	a := NewRandomBackoff(time.Second, time.Second, WithRand(rand.New(rand.NewSource(1))))
+	b := NewRandomBackoff(time.Second, time.Second, WithRand(rand.New(rand.NewSource(1))))
+	for i := 1; i <= 10; i++ {
+		assert.Equal(t, a(nil, i), b(nil, i))
+	}
+}
+
+func TestJitterDistribution(t *testing.T) {
+	assert.Equal(t, time.Second, NewRandomBackoff(time.Second, 0)(nil, 1))
+	assert.Equal(t, time.Second, NewExponentialRandomBackoff(time.Second, 2, 10*time.Second, 0)(nil, 1))
+	assert.Equal(t, time.Second, NewRandomBackoff(time.Second, time.Second, WithDistribution(NoJitter))(nil, 1))
+
+	normal := NewRandomBackoff(time.Second, time.Second, WithDistribution(Normal), WithRand(rand.New(rand.NewSource(1))))
+	total := time.Duration(0)
+	for i := 1; i <= 1000; i++ {
+		d := normal(nil, i)
+		assert.GreaterOrEqual(t, d, time.Second)
+		assert.Less(t, d, 2*time.Second)
+		total += d - time.Second
+	}
+	assert.InDelta(t, float64(500*time.Millisecond), float64(total/1000), float64(50*time.Millisecond))
+}
+
+func TestRelativeJitter(t *testing.T) {
+	s := NewBackoffWithRelativeJitter(NewExponentialBackoff(time.Second, 2, 0), 0.2)
+	for i := 1; i <= 5; i++ {
+		base := time.Second << (i - 1)
+		d := s(nil, i)
+		assert.GreaterOrEqual(t, d, base)
+		assert.Less(t, d, base+base/5)
+	}
+	assert.Equal(t, Stop, NewBackoffWithRelativeJitter(NewFixedBackoff(Stop), 0.2)(nil, 1))
+}
+
+func TestDeadlineSpread(t *testing.T) {
+	s := NewDeadlineSpread(NewFixedBackoff(time.Second))
+	assert.Equal(t, 3*time.Second, s(nil, 1, 9*time.Second, 2))
+	assert.Equal(t, 5*time.Second, s(nil, 2, 10*time.Second, 1))
+	assert.Equal(t, time.Second, s(nil, 1, 0, 2))
+	assert.Equal(t, time.Second, s(nil, 1, 10*time.Second, 0))
+}
+
+func TestAdaptiveBackoff(t *testing.T) {
+	b := NewAdaptiveBackoff(100*time.Millisecond, time.Second, 2, 150*time.Millisecond)
+	assert.Equal(t, 100*time.Millisecond, b.Next(nil, 1))
+	assert.Equal(t, 200*time.Millisecond, b.Next(nil, 2))
+	assert.Equal(t, 400*time.Millisecond, b.Next(nil, 1))
+	assert.Equal(t, 800*time.Millisecond, b.Current())
+	b.Reset()
+	assert.Equal(t, 650*time.Millisecond, b.Current())
+	assert.Equal(t, 650*time.Millisecond, b.Next(nil, 1))
+	assert.Equal(t, time.Second, b.Next(nil, 2))
+	assert.Equal(t, time.Second, b.Next(nil, 3))
+	for range 10 {
+		b.Reset()
+	}
+	assert.Equal(t, 100*time.Millisecond, b.Current())
+}
+
+func TestSchedule(t *testing.T) {
+	strategy := NewExponentialBackoff(200*time.Millisecond, 2, time.Second)
+	assert.Equal(t, []time.Duration{200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second}, Schedule(strategy, 4))
+	assert.Equal(t, "200ms, 400ms, 800ms", Describe(strategy, 3))
+	assert.Equal(t, "1s, 5s, stop", Describe(NewFiniteScheduleBackoff(time.Second, 5*time.Second), 4))
+}
@@ -0,0 +1,93 @@
+package backoff
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Option configures the randomness of the strategies that add jitter.
+type Option func(c *config)
+
+type config struct {
+	int63n       func(n int64) int64
+	normFloat64  func() float64
+	distribution Distribution
+}
+
+// Distribution is the distribution of the random jitter added to a backoff.
+type Distribution int
+
+const (
+	// Uniform jitter, every value in [0, jitter) is equally likely. This is the default.
+	Uniform Distribution = iota
+	// Normal jitter, values are centered on jitter/2 with a standard deviation of jitter/6, and clamped to [0, jitter).
+	Normal
+	// NoJitter disable the jitter.
+	NoJitter
+)
+
+// WithDistribution set the distribution of the jitter added by the strategies that take a jitter parameter.
+// It does not change the full and equal jitter algorithms.
+func WithDistribution(distribution Distribution) Option {
+	return func(c *config) {
+		c.distribution = distribution
+	}
+}
+
+// WithRand use the given random generator instead of the global one,
+// for example, a generator with a fixed seed for deterministic tests.
+// Calls to the generator are serialized, as a *rand.Rand is not safe for concurrent use,
+// so the global generator, which does not lock, is preferred when reproducibility is not needed.
+func WithRand(r *rand.Rand) Option {
+	mu := sync.Mutex{}
+	return func(c *config) {
+		c.int63n = func(n int64) int64 {
+			mu.Lock()
+			defer mu.Unlock()
+			return r.Int63n(n)
+		}
+		c.normFloat64 = func() float64 {
+			mu.Lock()
+			defer mu.Unlock()
+			return r.NormFloat64()
+		}
+	}
+}
+
+func newConfig(opts []Option) config {
+	c := config{int63n: rand.Int63n, normFloat64: rand.NormFloat64}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// jitter return a random duration in [0, jitter) following the configured distribution.
+// Return 0 if jitter is not positive.
+func (c config) jitter(jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return 0
+	}
+	switch c.distribution {
+	case NoJitter:
+		return 0
+	case Normal:
+		d := time.Duration(float64(jitter)/2 + c.normFloat64()*float64(jitter)/6)
+		return min(max(d, 0), jitter-1)
+	default:
+		return time.Duration(c.int63n(int64(jitter)))
+	}
+}
+
+// upTo return a random duration in [0, d].
+func (c config) upTo(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	if d == math.MaxInt64 {
+		return time.Duration(c.int63n(int64(d)))
+	}
+	return time.Duration(c.int63n(int64(d) + 1))
+}
@@ -0,0 +1,27 @@
+package try
+
+// Breaker is a circuit breaker shared by multiple retry loops, for example, a breaker.Breaker.
+// It is consulted right before every attempt, after the Limiter and the concurrency limit,
+// and informed of the outcome of every attempt. See WithBreaker.
+type Breaker interface {
+	// Allow report whether an attempt is allowed.
+	Allow() bool
+	// RecordSuccess record a successful attempt.
+	// Attempts failing with errors that are not retried also count as successes,
+	// as they do not indicate that the dependency is down, except context errors.
+	RecordSuccess()
+	// RecordFailure record a failed attempt with a retryable error, or a context.DeadlineExceeded.
+	RecordFailure()
+	// Release an allowed attempt that ended without outcome,
+	// for example, because the operation panicked or returned context.Canceled.
+	Release()
+}
+
+// WithBreaker consult the given Breaker before every attempt,
+// and give up with ErrCircuitOpen when it does not allow the attempt,
+// so repeated failures short-circuit future attempts instead of hammering a down dependency.
+func WithBreaker(breaker Breaker) RetryOption {
+	return func(options *Options) {
+		options.breaker = breaker
+	}
+}
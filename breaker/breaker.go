@@ -0,0 +1,141 @@
+// Package breaker provides a circuit breaker to use with try.WithBreaker.
+//
+// The breaker starts Closed, allowing every attempt.
+// After failureThreshold consecutive failures it becomes Open, rejecting every attempt for the cool-down.
+// Then it becomes HalfOpen, allowing a single trial attempt:
+// the breaker becomes Closed again if the trial succeeds, or Open for another cool-down if it fails.
+package breaker
+
+import (
+	"github.com/mawngo/go-try"
+	"sync"
+	"time"
+)
+
+var _ try.Breaker = (*Breaker)(nil)
+
+// State is the state of a Breaker.
+type State int
+
+const (
+	// Closed allow every attempt.
+	Closed State = iota
+	// Open reject every attempt until the cool-down ends.
+	Open
+	// HalfOpen allow a single trial attempt.
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Option configures a Breaker.
+type Option func(b *Breaker)
+
+// WithClock set the clock used to measure the cool-down, for example, a trytest.Clock.
+func WithClock(clock try.Clock) Option {
+	return func(b *Breaker) {
+		b.now = clock.Now
+	}
+}
+
+// Breaker is a circuit breaker, see the package documentation.
+// It is safe for concurrent use, and can be shared by multiple retry loops and Retrier.
+type Breaker struct {
+	mu               sync.Mutex
+	failureThreshold int
+	coolDown         time.Duration
+	now              func() time.Time
+	state            State
+	failures         int
+	openedAt         time.Time
+	trial            bool
+}
+
+// New create a Breaker that opens after failureThreshold consecutive failures, for the given cool-down.
+func New(failureThreshold int, coolDown time.Duration, opts ...Option) *Breaker {
+	b := &Breaker{
+		failureThreshold: max(failureThreshold, 1),
+		coolDown:         coolDown,
+		now:              time.Now,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// State return the current state.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == Open && b.now().Sub(b.openedAt) >= b.coolDown {
+		return HalfOpen
+	}
+	return b.state
+}
+
+// Allow implements try.Breaker.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case Open:
+		if b.now().Sub(b.openedAt) < b.coolDown {
+			return false
+		}
+		b.state = HalfOpen
+		b.trial = true
+		return true
+	case HalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	}
+	return true
+}
+
+// RecordSuccess implements try.Breaker, closing the breaker unless it is Open.
+// A late success from an attempt allowed before the breaker opened does not skip the cool-down.
+func (b *Breaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == Open {
+		return
+	}
+	b.state = Closed
+	b.failures = 0
+	b.trial = false
+}
+
+// RecordFailure implements try.Breaker.
+func (b *Breaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.state == HalfOpen || b.failures >= b.failureThreshold {
+		b.state = Open
+		b.openedAt = b.now()
+		b.trial = false
+	}
+}
+
+// Release implements try.Breaker, allowing another trial if the released attempt was the half-open trial.
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == HalfOpen {
+		b.trial = false
+	}
+}
@@ -0,0 +1,133 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/mawngo/go-try"
+	"github.com/mawngo/go-try/trytest"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var errFailed = errors.New("failed")
+
+func TestBreaker(t *testing.T) {
+	clock := trytest.NewClock(time.Unix(0, 0))
+	b := New(3, time.Minute, WithClock(clock))
+	r := try.New(try.WithBreaker(b), try.WithNoBackoff(), try.WithAttempts(5))
+
+	i := 0
+	err := r.Do(func() error {
+		i++
+		return errFailed
+	})
+	assert.ErrorIs(t, err, try.ErrCircuitOpen)
+	assert.ErrorIs(t, err, errFailed)
+	assert.Equal(t, 3, i)
+	assert.Equal(t, Open, b.State())
+
+	err = r.Do(func() error {
+		i++
+		return nil
+	})
+	assert.ErrorIs(t, err, try.ErrCircuitOpen)
+	assert.Equal(t, 3, i)
+
+	// After the cool-down, a failed trial opens the breaker again.
+	clock.Advance(time.Minute)
+	assert.Equal(t, HalfOpen, b.State())
+	err = r.Do(func() error {
+		i++
+		return errFailed
+	})
+	assert.ErrorIs(t, err, try.ErrCircuitOpen)
+	assert.Equal(t, 4, i)
+	assert.Equal(t, Open, b.State())
+
+	// A successful trial closes the breaker.
+	clock.Advance(time.Minute)
+	err = r.Do(func() error {
+		i++
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, i)
+	assert.Equal(t, Closed, b.State())
+}
+
+func TestBreakerNonRetryableError(t *testing.T) {
+	b := New(1, time.Minute)
+	err := try.Do(func() error {
+		return errFailed
+	}, try.WithBreaker(b), try.WithNoRetryFor(errFailed))
+	assert.ErrorIs(t, err, errFailed)
+	assert.Equal(t, Closed, b.State())
+}
+
+func TestBreakerPanickingTrial(t *testing.T) {
+	clock := trytest.NewClock(time.Unix(0, 0))
+	b := New(1, time.Minute, WithClock(clock))
+	err := try.Do(func() error {
+		return errFailed
+	}, try.WithBreaker(b), try.WithAttempts(1))
+	assert.ErrorIs(t, err, errFailed)
+	assert.Equal(t, Open, b.State())
+
+	// A trial that panics without reporting its outcome does not keep the breaker half-open forever.
+	clock.Advance(time.Minute)
+	assert.Panics(t, func() {
+		_ = try.Do(func() error {
+			panic("boom")
+		}, try.WithBreaker(b))
+	})
+	assert.Equal(t, HalfOpen, b.State())
+
+	err = try.Do(func() error {
+		return nil
+	}, try.WithBreaker(b))
+	assert.NoError(t, err)
+	assert.Equal(t, Closed, b.State())
+}
+
+func TestBreakerContextError(t *testing.T) {
+	clock := trytest.NewClock(time.Unix(0, 0))
+	b := New(3, time.Minute, WithClock(clock))
+	for range 5 {
+		_ = try.Do(func() error {
+			return fmt.Errorf("dial: %w", context.DeadlineExceeded)
+		}, try.WithBreaker(b))
+	}
+	assert.Equal(t, Open, b.State())
+
+	// A timed-out trial opens the breaker again.
+	clock.Advance(time.Minute)
+	err := try.Do(func() error {
+		return context.DeadlineExceeded
+	}, try.WithBreaker(b))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, Open, b.State())
+
+	// A canceled trial has no outcome, and allows another trial.
+	clock.Advance(time.Minute)
+	_ = try.Do(func() error {
+		return context.Canceled
+	}, try.WithBreaker(b))
+	assert.Equal(t, HalfOpen, b.State())
+	assert.True(t, b.Allow())
+}
+
+func TestBreakerLateSuccess(t *testing.T) {
+	clock := trytest.NewClock(time.Unix(0, 0))
+	b := New(1, time.Minute, WithClock(clock))
+	assert.True(t, b.Allow())
+	assert.True(t, b.Allow())
+	b.RecordFailure()
+	assert.Equal(t, Open, b.State())
+
+	// The attempt allowed before the breaker opened does not close it.
+	b.RecordSuccess()
+	assert.Equal(t, Open, b.State())
+	assert.False(t, b.Allow())
+}
@@ -0,0 +1,79 @@
+package try
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// ErrRetryBudgetExceed is returned when the retry budget carried by the context is used up,
+// or when the Budget configured using WithBudget does not allow retrying.
+// See ContextWithBudget and WithBudget.
+var ErrRetryBudgetExceed = errors.New("retry budget exceed")
+
+// Budget is a retry budget shared by multiple retry loops, for example, a trybudget.Budget.
+// It is consulted before every retry, and informed of the outcome of every attempt,
+// so retries can be throttled when too many attempts fail. See WithBudget.
+type Budget interface {
+	// AllowRetry report whether a retry is allowed.
+	AllowRetry() bool
+	// RecordSuccess record a successful attempt.
+	RecordSuccess()
+	// RecordFailure record a failed attempt with a retryable error.
+	RecordFailure()
+}
+
+// WithBudget consult the given Budget before every retry,
+// the error is returned immediately, wrapped in ErrRetryBudgetExceed, once the budget does not allow retrying.
+func WithBudget(budget Budget) RetryOption {
+	return func(options *Options) {
+		options.budget = budget
+	}
+}
+
+type budgetKey struct{}
+
+type retryBudget struct {
+	remaining atomic.Int64
+	parent    *retryBudget
+}
+
+// ContextWithBudget return a copy of ctx carrying a budget of the given number of retries.
+// Every retry loop using the returned context (or a context derived from it) via WithContext consumes the same budget,
+// so nested retries inside a retried operation do not multiply the number of attempts.
+// If ctx already carries a budget, retries must fit in both budgets.
+func ContextWithBudget(ctx context.Context, retries int) context.Context {
+	b := &retryBudget{}
+	b.remaining.Store(int64(retries))
+	if parent, ok := ctx.Value(budgetKey{}).(*retryBudget); ok {
+		b.parent = parent
+	}
+	return context.WithValue(ctx, budgetKey{}, b)
+}
+
+// RemainingBudget return the number of retries left in the budget carried by ctx.
+// The ok is false if ctx does not carry a budget.
+func RemainingBudget(ctx context.Context) (remaining int, ok bool) {
+	b, ok := ctx.Value(budgetKey{}).(*retryBudget)
+	if !ok {
+		return 0, false
+	}
+	remaining = int(max(b.remaining.Load(), 0))
+	for p := b.parent; p != nil; p = p.parent {
+		remaining = min(remaining, int(max(p.remaining.Load(), 0)))
+	}
+	return remaining, true
+}
+
+// take consume one retry from the budget and all its parents, return false if any of them is used up.
+func (b *retryBudget) take() bool {
+	for c := b; c != nil; c = c.parent {
+		if c.remaining.Add(-1) < 0 {
+			for r := b; r != c.parent; r = r.parent {
+				r.remaining.Add(1)
+			}
+			return false
+		}
+	}
+	return true
+}
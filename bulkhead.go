@@ -0,0 +1,62 @@
+package try
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrBulkheadFull is returned when the concurrency limit is reached in BulkheadFailFast mode.
+// See WithConcurrencyLimit.
+var ErrBulkheadFull = errors.New("bulkhead full")
+
+// BulkheadMode decide what happens to an attempt when the concurrency limit is reached.
+type BulkheadMode int
+
+const (
+	// BulkheadWait wait for a running attempt to finish, or for the context to be done.
+	BulkheadWait BulkheadMode = iota
+	// BulkheadFailFast give up immediately with ErrBulkheadFull.
+	BulkheadFailFast
+)
+
+type bulkhead struct {
+	sem  chan struct{}
+	mode BulkheadMode
+}
+
+// WithConcurrencyLimit limit the number of attempts running simultaneously, including retries,
+// across every retry using Options created with the returned RetryOption.
+// Create the RetryOption once, for example, in a shared Options or Retrier,
+// as each call to WithConcurrencyLimit creates a new limit.
+// Zero or negative n means no limit.
+func WithConcurrencyLimit(n int, mode BulkheadMode) RetryOption {
+	var b *bulkhead
+	if n > 0 {
+		b = &bulkhead{sem: make(chan struct{}, n), mode: mode}
+	}
+	return func(options *Options) {
+		options.bulkhead = b
+	}
+}
+
+// acquire a slot, return ErrBulkheadFull or the context error if not acquired.
+func (b *bulkhead) acquire(ctx context.Context) error {
+	select {
+	case b.sem <- struct{}{}:
+		return nil
+	default:
+	}
+	if b.mode == BulkheadFailFast {
+		return ErrBulkheadFull
+	}
+	select {
+	case b.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *bulkhead) release() {
+	<-b.sem
+}
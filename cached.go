@@ -0,0 +1,98 @@
+package try
+
+import (
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Cached return a getter that caches the result of the operation for ttl.
+// See CachedWithOptions.
+func Cached[T any](op func() (T, error), ttl time.Duration, retryOptions ...RetryOption) func() (T, error) {
+	option := NewOptions(retryOptions...)
+	return CachedWithOptions(op, ttl, option)
+}
+
+// CachedWithOptions return a getter that caches the result of the operation for ttl.
+// Once the value expired, or if there is no value yet, the getter refreshes it by performing the operation
+// with retry based on the options. Concurrent calls wait for the same refresh.
+// If the refresh still fails, the getter return the error.
+//
+// If WithServeStale is configured, the getter return the expired value immediately instead of waiting,
+// while a single refresh runs in the background, so callers are not delayed by a failing refresh.
+// A panic of a background refresh is always recovered, as no caller could recover it,
+// and the expired value keeps being served until a later refresh succeeds.
+func CachedWithOptions[T any](op func() (T, error), ttl time.Duration, options Options) func() (T, error) {
+	clock := options.getClock()
+	type flight struct {
+		done chan struct{}
+		err  error
+	}
+	var mu sync.Mutex
+	var value T
+	var cached bool
+	var expiry time.Time
+	var inflight *flight
+	refresh := func(f *flight, background bool) {
+		returned := false
+		defer func() {
+			var r any
+			if !returned {
+				r = recover()
+				f.err = &PanicError{Value: r, Stack: debug.Stack()}
+			}
+			mu.Lock()
+			inflight = nil
+			mu.Unlock()
+			close(f.done)
+			if !returned && !background {
+				panic(r)
+			}
+		}()
+		v, err := GetWithOptions(op, options)
+		returned = true
+		mu.Lock()
+		defer mu.Unlock()
+		f.err = err
+		if err == nil {
+			value, cached, expiry = v, true, clock.Now().Add(ttl)
+		}
+	}
+	return func() (T, error) {
+		mu.Lock()
+		if cached && (options.serveStale || clock.Now().Before(expiry)) {
+			if inflight == nil && !clock.Now().Before(expiry) {
+				inflight = &flight{done: make(chan struct{})}
+				go refresh(inflight, true)
+			}
+			v := value
+			mu.Unlock()
+			return v, nil
+		}
+		f := inflight
+		if f == nil {
+			f = &flight{done: make(chan struct{})}
+			inflight = f
+			mu.Unlock()
+			refresh(f, false)
+		} else {
+			mu.Unlock()
+			<-f.done
+		}
+		if f.err != nil {
+			var empty T
+			return empty, f.err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return value, nil
+	}
+}
+
+// WithServeStale let the getter returned by Cached return the expired value while refreshing it in the background.
+// It has no effect on Do and Get.
+func WithServeStale() RetryOption {
+	return func(options *Options) {
+		options.serveStale = true
+	}
+}
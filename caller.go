@@ -0,0 +1,43 @@
+package try
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+const packagePath = "github.com/mawngo/go-try"
+
+// caller return the file:line of the first frame outside this module,
+// which is the code that called Do, Get or one of their variants.
+func caller() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !isInternalFrame(frame) {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// callerFromContext return the location captured when the retry started, see WithOnRetryCallerLogging,
+// falling back to looking up the stack of the current goroutine.
+func callerFromContext(ctx context.Context) string {
+	if v, _ := ctx.Value(attemptKey{}).(attemptValue); v.caller != "" {
+		return v.caller
+	}
+	return caller()
+}
+
+func isInternalFrame(frame runtime.Frame) bool {
+	if strings.HasSuffix(frame.File, "_test.go") {
+		return false
+	}
+	return strings.HasPrefix(frame.Function, packagePath+".") || strings.HasPrefix(frame.Function, packagePath+"/")
+}
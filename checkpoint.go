@@ -0,0 +1,29 @@
+package try
+
+// DoResumable performs the given operation, resuming from the last committed checkpoint on retry.
+// See DoResumableWithOptions.
+func DoResumable[C any](start C, op func(from C, checkpoint func(C) error) error, commit func(C) error, retryOptions ...RetryOption) error {
+	option := NewOptions(retryOptions...)
+	return DoResumableWithOptions(start, op, commit, option)
+}
+
+// DoResumableWithOptions performs the given operation, resuming from the last committed checkpoint on retry.
+// The operation receives the checkpoint to start from, and a checkpoint function to call once it has processed up to a position.
+// The checkpoint function persists the position using commit, which may be nil,
+// and return its error so the operation can fail on it.
+// Only positions that were committed successfully are used to resume.
+func DoResumableWithOptions[C any](start C, op func(from C, checkpoint func(C) error) error, commit func(C) error, options Options) error {
+	from := start
+	checkpoint := func(c C) error {
+		if commit != nil {
+			if err := commit(c); err != nil {
+				return err
+			}
+		}
+		from = c
+		return nil
+	}
+	return DoWithOptions(func() error {
+		return op(from, checkpoint)
+	}, options)
+}
@@ -0,0 +1,50 @@
+package try
+
+import (
+	"errors"
+	"sync"
+)
+
+var registered = struct {
+	sync.RWMutex
+	retryable    []ErrorMatcher
+	nonRetryable []ErrorMatcher
+}{}
+
+// RegisterRetryable declare errors of type T (matched using errors.As) as retryable.
+// The registration is process-wide and consulted when no WithRetryIf or WithRetryFor is configured,
+// so a library can declare the retryability of its errors once for every caller.
+// Registered retryable errors are retried even if they are context errors.
+func RegisterRetryable[T error]() {
+	registered.Lock()
+	defer registered.Unlock()
+	registered.retryable = append(registered.retryable, ErrAs[T])
+}
+
+// RegisterNonRetryable declare err (matched using errors.Is) as not retryable.
+// The registration is process-wide and consulted when no WithRetryIf or WithRetryFor is configured.
+// It takes precedence over RegisterRetryable.
+func RegisterNonRetryable(err error) {
+	registered.Lock()
+	defer registered.Unlock()
+	registered.nonRetryable = append(registered.nonRetryable, func(e error) bool {
+		return errors.Is(e, err)
+	})
+}
+
+// classify return whether the error is registered as retryable or not, ok is false if it is not registered.
+func classify(err error) (retryable bool, ok bool) {
+	registered.RLock()
+	defer registered.RUnlock()
+	for _, matcher := range registered.nonRetryable {
+		if matcher(err) {
+			return false, true
+		}
+	}
+	for _, matcher := range registered.retryable {
+		if matcher(err) {
+			return true, true
+		}
+	}
+	return false, false
+}
@@ -0,0 +1,36 @@
+package try
+
+import (
+	"context"
+	"time"
+)
+
+// Clock provides the time to the retry loop.
+// Tests can replace it with a fake clock using WithClock, so backoff does not wait for real.
+type Clock interface {
+	// Now return the current time.
+	Now() time.Time
+	// Sleep wait for the given duration,
+	// and return the context error if the context is done before that.
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,56 @@
+package try
+
+import (
+	"context"
+)
+
+type optionsKey struct{}
+
+type attemptKey struct{}
+
+type attemptValue struct {
+	attempt int
+	lastErr error
+	caller  string
+}
+
+// AttemptFromContext return the number of the current attempt, starting from 1,
+// from the context passed to the operation and handlers by DoCtx, GetCtx and their variants.
+// The ok is false if ctx does not come from a retry.
+func AttemptFromContext(ctx context.Context) (attempt int, ok bool) {
+	v, ok := ctx.Value(attemptKey{}).(attemptValue)
+	return v.attempt, ok
+}
+
+// LastErrFromContext return the error of the previous attempt
+// from the context passed to the operation and handlers by DoCtx, GetCtx and their variants.
+// Return nil for the first attempt, or if ctx does not come from a retry.
+func LastErrFromContext(ctx context.Context) error {
+	v, _ := ctx.Value(attemptKey{}).(attemptValue)
+	return v.lastErr
+}
+
+// ContextWithOptions return a copy of ctx carrying the given retry options.
+// Every retry using the returned context (or a context derived from it) via WithContext
+// applies these options on top of its own, so callers of a library that retries internally
+// can override its attempts, backoff, etc. per request.
+// Options already carried by ctx are kept, and the given options are applied after them.
+func ContextWithOptions(ctx context.Context, retryOptions ...RetryOption) context.Context {
+	if existing, ok := ctx.Value(optionsKey{}).([]RetryOption); ok {
+		retryOptions = append(append([]RetryOption{}, existing...), retryOptions...)
+	}
+	return context.WithValue(ctx, optionsKey{}, retryOptions)
+}
+
+// applyContextOptions return the given options with the options carried by ctx applied.
+func applyContextOptions(ctx context.Context, options Options) Options {
+	overrides, ok := ctx.Value(optionsKey{}).([]RetryOption)
+	if !ok {
+		return options
+	}
+	applied := options
+	for _, o := range overrides {
+		o(&applied)
+	}
+	return applied
+}
@@ -0,0 +1,67 @@
+package try
+
+import (
+	"sync/atomic"
+)
+
+// DynamicOptions hold Options that can be replaced at runtime, for example, from a feature flag or an admin endpoint.
+// Use WithDynamicOptions to retry using them.
+// It must be created using NewDynamicOptions, and is safe for concurrent use.
+type DynamicOptions struct {
+	options atomic.Pointer[Options]
+}
+
+// NewDynamicOptions create a DynamicOptions holding the given options.
+func NewDynamicOptions(options Options) *DynamicOptions {
+	d := &DynamicOptions{}
+	d.Store(options)
+	return d
+}
+
+// Load return the current options.
+func (d *DynamicOptions) Load() Options {
+	return d.options.Load().Clone()
+}
+
+// Store replace the current options.
+func (d *DynamicOptions) Store(options Options) {
+	options = options.Clone()
+	d.options.Store(&options)
+}
+
+// Update replace the current options with the result of fn applied to them.
+// The fn may be called multiple times if the options are updated concurrently.
+func (d *DynamicOptions) Update(fn func(options Options) Options) {
+	for {
+		current := d.options.Load()
+		updated := fn(current.Clone())
+		if d.options.CompareAndSwap(current, &updated) {
+			return
+		}
+	}
+}
+
+// WithDynamicOptions use the policy of the DynamicOptions: the attempts, matchers, backoff,
+// max elapsed time and retry on context error.
+// The policy is reloaded after every failed attempt, so running retries pick up changes on their next retry,
+// replacing the policy configured by other options.
+// Handlers and other settings are not taken from the DynamicOptions.
+func WithDynamicOptions(d *DynamicOptions) RetryOption {
+	return func(options *Options) {
+		options.dynamic = d
+		options.loadPolicy(d.options.Load())
+	}
+}
+
+// loadPolicy copy the policy fields of the given options.
+func (o *Options) loadPolicy(p *Options) {
+	o.maxAttempts = p.maxAttempts
+	o.matcher = p.matcher
+	o.excludedMatcher = p.excludedMatcher
+	o.backoffStrategy = p.backoffStrategy
+	o.backoffReset = p.backoffReset
+	o.deadlineBackoff = p.deadlineBackoff
+	o.maxBackoff = p.maxBackoff
+	o.maxElapsedTime = p.maxElapsedTime
+	o.skipContextError = p.skipContextError
+}
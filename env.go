@@ -0,0 +1,80 @@
+package try
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// OptionsFromEnv create an Options from environment variables, starting from the defaults of NewOptions.
+// The variables are ATTEMPTS, BACKOFF, MAX_BACKOFF, JITTER and MAX_ELAPSED, each name prepended with prefix,
+// for example, prefix "TRY_" reads TRY_ATTEMPTS.
+// They follow the same rules as BindFlags: setting MAX_BACKOFF switches to exponential backoff, and BACKOFF 0 disables backoff.
+// Unset or empty variables keep the defaults.
+// The returned Options are validated using Options.Validate.
+func OptionsFromEnv(prefix string) (Options, error) {
+	options := NewOptions()
+	if v, ok := lookupEnv(prefix + "ATTEMPTS"); ok {
+		attempts, err := strconv.Atoi(v)
+		if err != nil {
+			return Options{}, fmt.Errorf("%sATTEMPTS: %w", prefix, err)
+		}
+		options.maxAttempts = attempts
+	}
+
+	initial, maximum, jitter := DefaultBackoff, time.Duration(0), time.Duration(0)
+	changed := false
+	for _, v := range []struct {
+		name string
+		d    *time.Duration
+	}{
+		{"BACKOFF", &initial},
+		{"MAX_BACKOFF", &maximum},
+		{"JITTER", &jitter},
+	} {
+		d, ok, err := durationFromEnv(prefix + v.name)
+		if err != nil {
+			return Options{}, err
+		}
+		if ok {
+			*v.d = d
+			changed = true
+		}
+	}
+	if changed {
+		options.backoffStrategy = flagBackoff(initial, maximum, jitter)
+		options.backoffReset = nil
+		options.deadlineBackoff = nil
+		options.maxBackoff = 0
+	}
+
+	d, ok, err := durationFromEnv(prefix + "MAX_ELAPSED")
+	if err != nil {
+		return Options{}, err
+	}
+	if ok {
+		options.maxElapsedTime = d
+	}
+	if err := options.Validate(); err != nil {
+		return Options{}, err
+	}
+	return options, nil
+}
+
+func lookupEnv(name string) (string, bool) {
+	v, ok := os.LookupEnv(name)
+	return v, ok && v != ""
+}
+
+func durationFromEnv(name string) (time.Duration, bool, error) {
+	v, ok := lookupEnv(name)
+	if !ok {
+		return 0, false, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, false, fmt.Errorf("%s: %w", name, err)
+	}
+	return d, true, nil
+}
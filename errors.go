@@ -0,0 +1,197 @@
+package try
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// RetryError is returned when the retry gave up while the error was still retryable,
+// for example, when the attempts are exhausted.
+// It satisfies errors.Is with the reason of giving up, such as ErrRetryAttemptsExceed, and with the last error.
+type RetryError struct {
+	reason   error
+	err      error
+	attempts int
+	elapsed  time.Duration
+}
+
+func newRetryError(reason error, err error, attempts int, elapsed time.Duration) *RetryError {
+	return &RetryError{
+		reason:   reason,
+		err:      err,
+		attempts: attempts,
+		elapsed:  elapsed,
+	}
+}
+
+func (e *RetryError) Error() string {
+	return e.reason.Error() + "\n" + e.err.Error()
+}
+
+// Unwrap return the reason of giving up and the last error.
+func (e *RetryError) Unwrap() []error {
+	return []error{e.reason, e.err}
+}
+
+// Reason return the reason of giving up, such as ErrRetryAttemptsExceed.
+func (e *RetryError) Reason() error {
+	return e.reason
+}
+
+// Attempts return the number of attempts made.
+func (e *RetryError) Attempts() int {
+	return e.attempts
+}
+
+// Elapsed return the time spent before giving up.
+func (e *RetryError) Elapsed() time.Duration {
+	return e.elapsed
+}
+
+// LastErr return the error of the last attempt,
+// joined with the last non-context error if the last attempt failed with a context error.
+func (e *RetryError) LastErr() error {
+	return e.err
+}
+
+// AttemptErrors is returned when WithCollectErrors is configured and the retry ends in failure.
+// It contains the error of every failed attempt, in addition to the error that would be returned otherwise.
+type AttemptErrors struct {
+	// Errs are the errors of the failed attempts, in order.
+	Errs []error
+	// Err is the error that would be returned without WithCollectErrors.
+	Err error
+}
+
+func (e *AttemptErrors) Error() string {
+	b := strings.Builder{}
+	b.WriteString(e.Err.Error())
+	for i, err := range e.Errs {
+		_, _ = fmt.Fprintf(&b, "\nattempt %d: %s", i+1, err)
+	}
+	return b.String()
+}
+
+// Unwrap return Err followed by the errors of the attempts.
+func (e *AttemptErrors) Unwrap() []error {
+	return append([]error{e.Err}, e.Errs...)
+}
+
+// PanicError is returned when the operation panicked and WithRecoverPanic is configured.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the goroutine at the time of the panic.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap return the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// callRecover performs the operation, converting a panic into a PanicError.
+func callRecover[T any](ctx context.Context, op func(ctx context.Context) (T, error)) (v T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return op(ctx)
+}
+
+type unrecoverableError struct {
+	err error
+}
+
+func (e *unrecoverableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *unrecoverableError) Unwrap() error {
+	return e.err
+}
+
+// Unrecoverable mark the error as not retryable,
+// even if the configured matchers would retry it, so the operation can abort the retry.
+// It takes precedence over ForceRetryable.
+// The returned error unwraps to err, nil is returned if err is nil.
+func Unrecoverable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &unrecoverableError{err: err}
+}
+
+// IsUnrecoverable report whether the error is marked by Unrecoverable.
+func IsUnrecoverable(err error) bool {
+	var e *unrecoverableError
+	return errors.As(err, &e)
+}
+
+type retryAfterError struct {
+	err   error
+	after time.Duration
+}
+
+func (e *retryAfterError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryAfterError) Unwrap() error {
+	return e.err
+}
+
+// RetryAfter implements backoff.RetryAfterHint.
+func (e *retryAfterError) RetryAfter() time.Duration {
+	return e.after
+}
+
+// WithRetryAfter attach a backoff hint to the error, used by backoff.NewHintAwareBackoff.
+// The returned error unwraps to err, nil is returned if err is nil.
+func WithRetryAfter(err error, after time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &retryAfterError{err: err, after: after}
+}
+
+type forceRetryableError struct {
+	err error
+}
+
+func (e *forceRetryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *forceRetryableError) Unwrap() error {
+	return e.err
+}
+
+// ForceRetryable mark the error as retryable,
+// even if the configured matchers, exclusions or registrations would not retry it.
+// The attempts limit still applies.
+// The returned error unwraps to err, nil is returned if err is nil.
+func ForceRetryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &forceRetryableError{err: err}
+}
+
+// IsForceRetryable report whether the error is marked by ForceRetryable.
+func IsForceRetryable(err error) bool {
+	var e *forceRetryableError
+	return errors.As(err, &e)
+}
@@ -0,0 +1,46 @@
+package try
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Fallback is an operation used by GetWithFallbacks, with its own retry options.
+type Fallback[T any] struct {
+	op      func(ctx context.Context) (T, error)
+	options Options
+}
+
+// NewFallback create a Fallback retrying the operation based on the given retryOptions.
+func NewFallback[T any](op func(ctx context.Context) (T, error), retryOptions ...RetryOption) Fallback[T] {
+	return NewFallbackWithOptions(op, NewOptions(retryOptions...))
+}
+
+// NewFallbackWithOptions create a Fallback retrying the operation based on the given options.
+func NewFallbackWithOptions[T any](op func(ctx context.Context) (T, error), options Options) Fallback[T] {
+	return Fallback[T]{op: op, options: options}
+}
+
+// GetWithFallbacks performs the primary operation until it succeeds or gives up,
+// then moves to each fallback in order, for example, a cache, then a database, then a remote service.
+// Each of them is retried based on its own options.
+// Return the result and the index of the operation that succeeded, 0 for the primary and i for the ith fallback.
+// If all of them still failed, the index is -1 and the error is the joined errors of the operations,
+// each annotated with the index of its operation.
+// The fallbacks are not tried once ctx is done.
+func GetWithFallbacks[T any](ctx context.Context, primary Fallback[T], fallbacks ...Fallback[T]) (v T, source int, err error) {
+	errs := make([]error, 0, len(fallbacks)+1)
+	for i, f := range append([]Fallback[T]{primary}, fallbacks...) {
+		if i > 0 && ctx.Err() != nil {
+			break
+		}
+		v, err = GetCtxWithOptions(ctx, f.op, f.options)
+		if err == nil {
+			return v, i, nil
+		}
+		errs = append(errs, fmt.Errorf("operation %d: %w", i, err))
+	}
+	var empty T
+	return empty, -1, errors.Join(errs...)
+}
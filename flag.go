@@ -0,0 +1,42 @@
+package try
+
+import (
+	"flag"
+	"github.com/mawngo/go-try/backoff"
+	"time"
+)
+
+// BindFlags register retry flags to the flag set, and return a RetryOption that apply their values.
+// The registered flags are -retry-attempts, -retry-backoff, -retry-max-backoff and -retry-jitter,
+// each name prepended with prefix, for example, prefix "db-" registers -db-retry-attempts.
+// Setting -retry-max-backoff switches to exponential backoff, and -retry-backoff 0 disables backoff.
+// The returned RetryOption reads the flags when applied, so it must be used after the flag set is parsed.
+func BindFlags(fs *flag.FlagSet, prefix string) RetryOption {
+	attempts := fs.Int(prefix+"retry-attempts", DefaultMaxAttempts, "maximum number of attempts, 0 means unlimited")
+	initial := fs.Duration(prefix+"retry-backoff", DefaultBackoff, "wait time between retries, 0 means no backoff")
+	maximum := fs.Duration(prefix+"retry-max-backoff", 0, "maximum wait time between retries, enable exponential backoff if set")
+	jitter := fs.Duration(prefix+"retry-jitter", 0, "maximum random jitter added to the wait time between retries")
+	return func(options *Options) {
+		options.maxAttempts = *attempts
+		options.backoffStrategy = flagBackoff(*initial, *maximum, *jitter)
+		options.backoffReset = nil
+		options.deadlineBackoff = nil
+		options.maxBackoff = 0
+	}
+}
+
+func flagBackoff(initial time.Duration, maximum time.Duration, jitter time.Duration) backoff.Strategy {
+	if initial <= 0 {
+		return nil
+	}
+	if maximum > 0 {
+		if jitter > 0 {
+			return backoff.NewExponentialRandomBackoff(initial, defaultMultiplier, maximum, jitter)
+		}
+		return backoff.NewExponentialBackoff(initial, defaultMultiplier, maximum)
+	}
+	if jitter > 0 {
+		return backoff.NewRandomBackoff(initial, jitter)
+	}
+	return backoff.NewFixedBackoff(initial)
+}
@@ -0,0 +1,58 @@
+package try
+
+// Future is the result of an operation retried in the background by GoDo or GoGet.
+type Future[T any] struct {
+	done chan struct{}
+	v    T
+	err  error
+}
+
+// Done return a channel that is closed once the operation succeeded or the retry gave up.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Result wait for the operation to finish, and return its result.
+func (f *Future[T]) Result() (T, error) {
+	<-f.done
+	return f.v, f.err
+}
+
+// Err wait for the operation to finish, and return its error.
+func (f *Future[T]) Err() error {
+	<-f.done
+	return f.err
+}
+
+// GoDo performs the given operation in a new goroutine, and return a Future of its outcome.
+// See GoDoWithOptions.
+func GoDo(op func() error, retryOptions ...RetryOption) *Future[struct{}] {
+	option := NewOptions(retryOptions...)
+	return GoDoWithOptions(op, option)
+}
+
+// GoDoWithOptions performs the given operation in a new goroutine, and return a Future of its outcome.
+// See DoWithOptions.
+func GoDoWithOptions(op func() error, options Options) *Future[struct{}] {
+	return GoGetWithOptions(func() (struct{}, error) {
+		return struct{}{}, op()
+	}, options)
+}
+
+// GoGet performs the given operation in a new goroutine, and return a Future of its result.
+// See GoGetWithOptions.
+func GoGet[T any](op func() (T, error), retryOptions ...RetryOption) *Future[T] {
+	option := NewOptions(retryOptions...)
+	return GoGetWithOptions(op, option)
+}
+
+// GoGetWithOptions performs the given operation in a new goroutine, and return a Future of its result.
+// See GetWithOptions.
+func GoGetWithOptions[T any](op func() (T, error), options Options) *Future[T] {
+	f := &Future[T]{done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		f.v, f.err = GetWithOptions(op, options)
+	}()
+	return f
+}
@@ -0,0 +1,94 @@
+package try
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Group run tasks concurrently, each retried independently, similar to errgroup.Group.
+// Create it using NewGroup.
+type Group struct {
+	ctx                 context.Context
+	cancel              context.CancelFunc
+	options             Options
+	sem                 chan struct{}
+	wg                  sync.WaitGroup
+	mu                  sync.Mutex
+	errs                []error
+	n                   int
+	cancelUnrecoverable bool
+}
+
+// NewGroup create a Group, the tasks are retried based on the given retryOptions.
+// The number of tasks running at the same time can be limited using WithParallelism.
+func NewGroup(ctx context.Context, retryOptions ...RetryOption) *Group {
+	return NewGroupWithOptions(ctx, NewOptions(retryOptions...))
+}
+
+// NewGroupWithOptions create a Group, the tasks are retried based on the given options.
+// See NewGroup.
+func NewGroupWithOptions(ctx context.Context, options Options) *Group {
+	ctx, cancel := context.WithCancel(ctx)
+	g := &Group{ctx: ctx, cancel: cancel, options: options}
+	if options.parallelism > 0 {
+		g.sem = make(chan struct{}, options.parallelism)
+	}
+	return g
+}
+
+// Context return the context passed to the tasks,
+// which is canceled when Wait returns, or when a task fails with an unrecoverable error if SetCancelOnUnrecoverable is enabled.
+func (g *Group) Context() context.Context {
+	return g.ctx
+}
+
+// SetCancelOnUnrecoverable cancel the context of the group as soon as a task fails with an unrecoverable error,
+// see Unrecoverable, so the other tasks can stop early.
+// Tasks giving up for other reasons, such as running out of attempts, do not cancel the group.
+// It must be called before Go.
+func (g *Group) SetCancelOnUnrecoverable(cancel bool) {
+	g.cancelUnrecoverable = cancel
+}
+
+// Go run the task in a new goroutine, retried based on the options of the group.
+// If the number of running tasks is limited, Go blocks until a task finishes.
+func (g *Group) Go(op func(ctx context.Context) error) {
+	g.mu.Lock()
+	i := g.n
+	g.n++
+	g.mu.Unlock()
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	g.wg.Add(1)
+	go func() {
+		defer func() {
+			if g.sem != nil {
+				<-g.sem
+			}
+			g.wg.Done()
+		}()
+		err := DoCtxWithOptions(g.ctx, op, g.options)
+		if err == nil {
+			return
+		}
+		g.mu.Lock()
+		g.errs = append(g.errs, fmt.Errorf("task %d: %w", i, err))
+		g.mu.Unlock()
+		if g.cancelUnrecoverable && IsUnrecoverable(err) {
+			g.cancel()
+		}
+	}()
+}
+
+// Wait for all the tasks to finish, and return the joined errors of the tasks that still failed,
+// each annotated with the index of its task, in the order they failed.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return errors.Join(g.errs...)
+}
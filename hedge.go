@@ -0,0 +1,107 @@
+package try
+
+import (
+	"context"
+	"runtime/debug"
+	"time"
+)
+
+// Hedge performs the given operation, launching speculative attempts when it is slow.
+// See GetHedgedWithOptions.
+func Hedge(ctx context.Context, op func(ctx context.Context) error, hedgeDelay time.Duration, maxHedges int, retryOptions ...RetryOption) error {
+	option := NewOptions(retryOptions...)
+	return HedgeWithOptions(ctx, op, hedgeDelay, maxHedges, option)
+}
+
+// HedgeWithOptions performs the given operation, launching speculative attempts when it is slow.
+// See GetHedgedWithOptions.
+func HedgeWithOptions(ctx context.Context, op func(ctx context.Context) error, hedgeDelay time.Duration, maxHedges int, options Options) error {
+	_, err := GetHedgedWithOptions(ctx, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, op(ctx)
+	}, hedgeDelay, maxHedges, options)
+	return err
+}
+
+// GetHedged performs the given operation, launching speculative attempts when it is slow, and return the result.
+// See GetHedgedWithOptions.
+func GetHedged[T any](ctx context.Context, op func(ctx context.Context) (T, error), hedgeDelay time.Duration, maxHedges int, retryOptions ...RetryOption) (T, error) {
+	option := NewOptions(retryOptions...)
+	return GetHedgedWithOptions(ctx, op, hedgeDelay, maxHedges, option)
+}
+
+// GetHedgedWithOptions performs the given operation, and return the result.
+// If the operation has not completed within hedgeDelay, up to maxHedges additional attempts are launched concurrently,
+// one every hedgeDelay, the first success is returned and the context of the others is canceled.
+// When all the concurrent attempts fail, the error of the last one is retried based on the options,
+// starting a new round of hedged attempts.
+// A negative maxHedges is treated as 0.
+// The hedgeDelay is measured using the Clock of the options, and panics of the speculative attempts
+// are recovered if WithRecoverPanic is configured, or propagate to the caller otherwise.
+func GetHedgedWithOptions[T any](ctx context.Context, op func(ctx context.Context) (T, error), hedgeDelay time.Duration, maxHedges int, options Options) (T, error) {
+	maxHedges = max(maxHedges, 0)
+	clock, recoverPanic := options.getClock(), options.recoverPanic
+	return GetCtxWithOptions(ctx, func(ctx context.Context) (T, error) {
+		return hedge(ctx, op, hedgeDelay, maxHedges, clock, recoverPanic)
+	}, options)
+}
+
+func hedge[T any](ctx context.Context, op func(ctx context.Context) (T, error), hedgeDelay time.Duration, maxHedges int, clock Clock, recoverPanic bool) (T, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type result struct {
+		v        T
+		err      error
+		panicked bool
+	}
+	results := make(chan result, maxHedges+1)
+	launch := func() {
+		go func() {
+			var r result
+			// A panic cannot be recovered by the caller in another goroutine,
+			// so it is recovered here and propagated or converted to a PanicError.
+			defer func() {
+				if p := recover(); p != nil {
+					r = result{err: &PanicError{Value: p, Stack: debug.Stack()}, panicked: !recoverPanic}
+				}
+				results <- r
+			}()
+			r.v, r.err = op(ctx)
+		}()
+	}
+
+	// The ticks are produced by sleeping on the clock, so a fake clock controls the hedging.
+	ticks := make(chan struct{})
+	go func() {
+		for range maxHedges {
+			if clock.Sleep(ctx, hedgeDelay) != nil {
+				return
+			}
+			select {
+			case ticks <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	launch()
+	pending := 1
+	for {
+		select {
+		case r := <-results:
+			pending--
+			if r.panicked {
+				panic(r.err.(*PanicError).Value)
+			}
+			if r.err == nil {
+				return r.v, nil
+			}
+			if pending == 0 {
+				return r.v, r.err
+			}
+		case <-ticks:
+			launch()
+			pending++
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package try
+
+// hookRunner runs the handlers of a retry loop, either inline or asynchronously.
+type hookRunner struct {
+	size  int
+	queue chan func()
+}
+
+// run the handler inline if the runner is synchronous,
+// otherwise queue it to the background goroutine, dropping it if the queue is full.
+func (r *hookRunner) run(handler func()) {
+	if r.size <= 0 {
+		handler()
+		return
+	}
+	r.start()
+	select {
+	case r.queue <- handler:
+	default:
+	}
+}
+
+// runFinal run the handler like run, but wait for room in the queue instead of dropping it,
+// as the give-up and success handlers are promised to be called once.
+func (r *hookRunner) runFinal(handler func()) {
+	if r.size <= 0 {
+		handler()
+		return
+	}
+	r.start()
+	r.queue <- handler
+}
+
+// start the background goroutine if it is not running yet.
+func (r *hookRunner) start() {
+	if r.queue == nil {
+		r.queue = make(chan func(), r.size)
+		go func(queue chan func()) {
+			for h := range queue {
+				h()
+			}
+		}(r.queue)
+	}
+}
+
+// close let the background goroutine exit after running the queued handlers.
+func (r *hookRunner) close() {
+	if r.queue != nil {
+		close(r.queue)
+	}
+}
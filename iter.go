@@ -0,0 +1,50 @@
+//go:build go1.23
+
+package try
+
+import (
+	"context"
+	"iter"
+)
+
+// Attempts return an iterator over the attempt numbers of a retry, starting from 1,
+// so the operation can be written as the body of a range loop.
+// See AttemptsWithOptions.
+func Attempts(ctx context.Context, err *error, retryOptions ...RetryOption) iter.Seq[int] {
+	option := NewOptions(retryOptions...)
+	return AttemptsWithOptions(ctx, err, option)
+}
+
+// AttemptsWithOptions return an iterator over the attempt numbers of a retry, starting from 1,
+// so the operation can be written as the body of a range loop.
+// The loop body reports the result of each attempt by assigning the error to *err,
+// the iteration continues while the attempt failed and can be retried based on the options,
+// then *err is set to the final error, like the one returned by DoWithOptions.
+// Breaking out of the loop stops retrying, leaving *err as assigned by the body.
+// The retry stops when ctx is canceled, the given ctx replaces the context configured by WithContext.
+//
+//	var err error
+//	for range try.Attempts(ctx, &err, try.WithAttempts(3)) {
+//		err = call(ctx)
+//	}
+//
+// Panics are never recovered, WithRecoverPanic is ignored.
+func AttemptsWithOptions(ctx context.Context, err *error, options Options) iter.Seq[int] {
+	options.context = ctx
+	options.recoverPanic = false
+	return func(yield func(int) bool) {
+		stopped := false
+		_, e := retry(func(ctx context.Context) (struct{}, error) {
+			attempt, _ := AttemptFromContext(ctx)
+			*err = nil
+			if !yield(attempt) {
+				stopped = true
+				return struct{}{}, Unrecoverable(*err)
+			}
+			return struct{}{}, *err
+		}, options, true)
+		if !stopped {
+			*err = e
+		}
+	}
+}
@@ -0,0 +1,55 @@
+//go:build go1.23
+
+package try
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAttempts(t *testing.T) {
+	var err error
+	i := 0
+	for attempt := range Attempts(context.Background(), &err, WithNoBackoff()) {
+		i++
+		assert.Equal(t, i, attempt)
+		if attempt < 3 {
+			err = errFailed
+		}
+	}
+	assert.Nil(t, err)
+	assert.Equal(t, 3, i)
+
+	i = 0
+	for range Attempts(context.Background(), &err, WithNoBackoff(), WithAttempts(2)) {
+		i++
+		err = errFailed
+	}
+	assert.True(t, errors.Is(err, ErrRetryAttemptsExceed))
+	assert.Equal(t, 2, i)
+
+	i = 0
+	for range Attempts(context.Background(), &err, WithNoBackoff()) {
+		i++
+		err = errFailed
+		break
+	}
+	assert.Equal(t, errFailed, err)
+	assert.Equal(t, 1, i)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	i = 0
+	for range Attempts(ctx, &err, WithNoBackoff(), WithUnlimitedAttempts()) {
+		i++
+		err = errFailed
+		if i == 2 {
+			cancel()
+		}
+	}
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, err, errFailed)
+	assert.Equal(t, 2, i)
+}
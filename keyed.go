@@ -0,0 +1,102 @@
+package try
+
+import (
+	"context"
+	"github.com/mawngo/go-try/backoff"
+	"sync"
+	"time"
+)
+
+// KeyedRetrier performs operations like a Retrier, but remembers the failures per key, for example, per downstream host,
+// so successive failures for the same key continue the backoff curve across calls instead of restarting at the initial backoff.
+// A success resets the failures of the key.
+// A KeyedRetrier is safe for concurrent use.
+type KeyedRetrier[K comparable] struct {
+	options  Options
+	mu       sync.Mutex
+	failures map[K]int
+}
+
+// NewKeyed create a KeyedRetrier.
+// See NewOptions for defaults.
+func NewKeyed[K comparable](retryOptions ...RetryOption) *KeyedRetrier[K] {
+	return NewKeyedWithOptions[K](NewOptions(retryOptions...))
+}
+
+// NewKeyedWithOptions create a KeyedRetrier using the given options.
+func NewKeyedWithOptions[K comparable](options Options) *KeyedRetrier[K] {
+	return &KeyedRetrier[K]{options: options, failures: make(map[K]int)}
+}
+
+// Do performs the given operation for the key.
+// See DoWithOptions.
+func (r *KeyedRetrier[K]) Do(key K, op func() error) error {
+	_, err := GetWithKeyedRetrier(key, func() (struct{}, error) {
+		return struct{}{}, op()
+	}, r)
+	return err
+}
+
+// DoCtx performs the given operation for the key, passing it the context of the retry.
+// See DoCtxWithOptions.
+func (r *KeyedRetrier[K]) DoCtx(ctx context.Context, key K, op func(ctx context.Context) error) error {
+	_, err := GetCtxWithKeyedRetrier(ctx, key, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, op(ctx)
+	}, r)
+	return err
+}
+
+// Failures return the number of consecutive failed attempts recorded for the key.
+func (r *KeyedRetrier[K]) Failures(key K) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.failures[key]
+}
+
+// Reset forget the failures of the key.
+func (r *KeyedRetrier[K]) Reset(key K) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.failures, key)
+}
+
+// GetWithKeyedRetrier performs the given operation for the key using the KeyedRetrier, and return the result.
+// See GetWithOptions.
+func GetWithKeyedRetrier[K comparable, T any](key K, op func() (T, error), r *KeyedRetrier[K]) (T, error) {
+	return GetCtxWithKeyedRetrier(r.options.context, key, func(_ context.Context) (T, error) {
+		return op()
+	}, r)
+}
+
+// GetCtxWithKeyedRetrier performs the given operation for the key using the KeyedRetrier,
+// passing it the context of the retry, and return the result.
+// See GetCtxWithOptions.
+func GetCtxWithKeyedRetrier[K comparable, T any](ctx context.Context, key K, op func(ctx context.Context) (T, error), r *KeyedRetrier[K]) (T, error) {
+	options := r.options
+	offset := r.Failures(key)
+	if offset > 0 {
+		options.decorateBackoff(func(strategy backoff.Strategy) backoff.Strategy {
+			return func(err error, i int) time.Duration {
+				return strategy(err, i+offset)
+			}
+		})
+	}
+
+	failed := 0
+	v, err := GetCtxWithOptions(ctx, func(ctx context.Context) (T, error) {
+		v, err := op(ctx)
+		if err != nil {
+			failed++
+		}
+		return v, err
+	}, options)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err == nil {
+		delete(r.failures, key)
+	} else {
+		r.failures[key] += failed
+	}
+	return v, err
+}
@@ -0,0 +1,22 @@
+package try
+
+import (
+	"context"
+)
+
+// Limiter pace the attempts, for example, a *rate.Limiter from golang.org/x/time/rate.
+// See WithLimiter.
+type Limiter interface {
+	// Wait block until an attempt is allowed, or return an error if the context is done before that.
+	Wait(ctx context.Context) error
+}
+
+// WithLimiter wait on the given Limiter before every attempt, including the first one,
+// for APIs with strict request-per-second quotas.
+// The wait happens in addition to the backoff, use WithNoBackoff to pace retries using the limiter only.
+// The retry gives up with the error of the limiter if waiting fails.
+func WithLimiter(limiter Limiter) RetryOption {
+	return func(options *Options) {
+		options.limiter = limiter
+	}
+}
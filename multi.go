@@ -0,0 +1,84 @@
+package try
+
+import (
+	"context"
+)
+
+type pair[A any, B any] struct {
+	a A
+	b B
+}
+
+type triple[A any, B any, C any] struct {
+	a A
+	b B
+	c C
+}
+
+// Get2 performs the given operation, and return its two results.
+// See Get2WithOptions.
+func Get2[A any, B any](op func() (A, B, error), retryOptions ...RetryOption) (A, B, error) {
+	option := NewOptions(retryOptions...)
+	return Get2WithOptions(op, option)
+}
+
+// Get2WithOptions performs the given operation, and return its two results.
+// See GetWithOptions.
+func Get2WithOptions[A any, B any](op func() (A, B, error), options Options) (A, B, error) {
+	v, err := GetWithOptions(func() (pair[A, B], error) {
+		a, b, err := op()
+		return pair[A, B]{a, b}, err
+	}, options)
+	return v.a, v.b, err
+}
+
+// Get2Ctx performs the given operation, passing it the context of the retry, and return its two results.
+// See Get2CtxWithOptions.
+func Get2Ctx[A any, B any](ctx context.Context, op func(ctx context.Context) (A, B, error), retryOptions ...RetryOption) (A, B, error) {
+	option := NewOptions(retryOptions...)
+	return Get2CtxWithOptions(ctx, op, option)
+}
+
+// Get2CtxWithOptions performs the given operation, passing it the context of the retry, and return its two results.
+// See GetCtxWithOptions.
+func Get2CtxWithOptions[A any, B any](ctx context.Context, op func(ctx context.Context) (A, B, error), options Options) (A, B, error) {
+	v, err := GetCtxWithOptions(ctx, func(ctx context.Context) (pair[A, B], error) {
+		a, b, err := op(ctx)
+		return pair[A, B]{a, b}, err
+	}, options)
+	return v.a, v.b, err
+}
+
+// Get3 performs the given operation, and return its three results.
+// See Get3WithOptions.
+func Get3[A any, B any, C any](op func() (A, B, C, error), retryOptions ...RetryOption) (A, B, C, error) {
+	option := NewOptions(retryOptions...)
+	return Get3WithOptions(op, option)
+}
+
+// Get3WithOptions performs the given operation, and return its three results.
+// See GetWithOptions.
+func Get3WithOptions[A any, B any, C any](op func() (A, B, C, error), options Options) (A, B, C, error) {
+	v, err := GetWithOptions(func() (triple[A, B, C], error) {
+		a, b, c, err := op()
+		return triple[A, B, C]{a, b, c}, err
+	}, options)
+	return v.a, v.b, v.c, err
+}
+
+// Get3Ctx performs the given operation, passing it the context of the retry, and return its three results.
+// See Get3CtxWithOptions.
+func Get3Ctx[A any, B any, C any](ctx context.Context, op func(ctx context.Context) (A, B, C, error), retryOptions ...RetryOption) (A, B, C, error) {
+	option := NewOptions(retryOptions...)
+	return Get3CtxWithOptions(ctx, op, option)
+}
+
+// Get3CtxWithOptions performs the given operation, passing it the context of the retry, and return its three results.
+// See GetCtxWithOptions.
+func Get3CtxWithOptions[A any, B any, C any](ctx context.Context, op func(ctx context.Context) (A, B, C, error), options Options) (A, B, C, error) {
+	v, err := GetCtxWithOptions(ctx, func(ctx context.Context) (triple[A, B, C], error) {
+		a, b, c, err := op(ctx)
+		return triple[A, B, C]{a, b, c}, err
+	}, options)
+	return v.a, v.b, v.c, err
+}
@@ -0,0 +1,35 @@
+package try
+
+import (
+	"sync"
+)
+
+// InitOnce return a function that performs the given initializer, and memoize the result after the first success.
+// See InitOnceWithOptions.
+func InitOnce[T any](op func() (T, error), retryOptions ...RetryOption) func() (T, error) {
+	option := NewOptions(retryOptions...)
+	return InitOnceWithOptions(op, option)
+}
+
+// InitOnceWithOptions return a function that performs the given initializer, and memoize the result after the first success.
+// Until the initializer succeeds, every call retries it based on the options and return the error if it still failed.
+// Concurrent calls wait for the in-progress initialization instead of running their own.
+func InitOnceWithOptions[T any](op func() (T, error), options Options) func() (T, error) {
+	mu := sync.Mutex{}
+	done := false
+	var value T
+	return func() (T, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if done {
+			return value, nil
+		}
+		v, err := GetWithOptions(op, options)
+		if err != nil {
+			return v, err
+		}
+		value = v
+		done = true
+		return value, nil
+	}
+}
@@ -3,8 +3,10 @@
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/mawngo/go-try/backoff"
 	"log/slog"
+	"math"
 	"time"
 )
 
@@ -12,14 +14,55 @@
 const DefaultMaxAttempts = 5
 const defaultMultiplier = 2
 
+// ErrInvalidOptions is wrapped by the errors returned by Options.Validate.
+var ErrInvalidOptions = errors.New("invalid options")
+
+// defaultBackoffStrategy is shared by all options, so creating default options does not allocate.
+var defaultBackoffStrategy = backoff.NewFixedBackoff(DefaultBackoff)
+
+func defaultOptions() Options {
+	return Options{
+		backoffStrategy:  defaultBackoffStrategy,
+		maxAttempts:      DefaultMaxAttempts,
+		skipContextError: true,
+	}
+}
+
 type Options struct {
-	context          context.Context
-	maxAttempts      int
-	matcher          ErrorMatcher
-	excludedMatcher  ErrorMatcher
-	backoffStrategy  backoff.Strategy
-	onRetry          OnRetryHandler
-	skipContextError bool
+	context              context.Context
+	maxAttempts          int
+	matcher              ErrorMatcher
+	excludedMatcher      ErrorMatcher
+	backoffStrategy      backoff.Strategy
+	backoffReset         func()
+	onRetry              OnRetryHandler
+	onRetryInfo          OnRetryInfoHandler
+	onRetryBeforeBackoff bool
+	onGiveUp             func(ctx context.Context, err error, attempts int, elapsed time.Duration)
+	onSuccess            OnSuccessHandler
+	skipContextError     bool
+	parallelism          int
+	asyncQueueSize       int
+	maxElapsedTime       time.Duration
+	recoverPanic         bool
+	report               *Report
+	collectErrors        bool
+	minRemainingDeadline time.Duration
+	clock                Clock
+	invalid              []error
+	dynamic              *DynamicOptions
+	timeout              time.Duration
+	maxTotalBackoff      time.Duration
+	deadlineBackoff      backoff.DeadlineStrategy
+	maxBackoff           time.Duration
+	budget               Budget
+	breaker              Breaker
+	limiter              Limiter
+	bulkhead             *bulkhead
+	serveStale           bool
+	captureCaller        bool
+	contextDecorator     func(ctx context.Context, attempt int) context.Context
+	attemptTimeouts      []time.Duration
 }
 
 // ErrorMatcher match the error, return true if matched.
@@ -41,9 +84,25 @@ func ErrIs(err error) ErrorMatcher {
 // OnRetryHandler handler that will be called for each retry.
 type OnRetryHandler func(ctx context.Context, err error, i int)
 
+// RetryInfo describes a retry that is about to happen.
+type RetryInfo struct {
+	// Retry is the number of the retry, starting from 1.
+	Retry int
+	// Err is the error of the failed attempt.
+	Err error
+	// Backoff is the time that will be waited before the next attempt.
+	Backoff time.Duration
+	// Elapsed is the time spent since the first attempt started.
+	Elapsed time.Duration
+}
+
+// OnRetryInfoHandler handler that will be called for each retry, before waiting for the backoff.
+type OnRetryInfoHandler func(ctx context.Context, info RetryInfo)
+
 // NewOnRetryLoggingHandler return a OnRetryHandler that log a message on each retry.
 func NewOnRetryLoggingHandler(level slog.Level, msg string) OnRetryHandler {
 	return func(ctx context.Context, err error, i int) {
+		level := level
 		if i >= DefaultMaxAttempts {
 			level = slog.LevelError
 		}
@@ -57,6 +116,70 @@ func WithOnRetryLogging(level slog.Level, msg string) RetryOption {
 	return WithOnRetry(NewOnRetryLoggingHandler(level, msg))
 }
 
+// NewOnRetryCallerLoggingHandler return a OnRetryHandler that log a message on each retry,
+// including the location of the code that started the retry.
+// The location is captured when the retry starts if configured using WithOnRetryCallerLogging,
+// otherwise it is looked up when the handler runs, which does not work with WithAsyncHandlers.
+func NewOnRetryCallerLoggingHandler(level slog.Level, msg string) OnRetryHandler {
+	return func(ctx context.Context, err error, i int) {
+		level := level
+		if i >= DefaultMaxAttempts {
+			level = slog.LevelError
+		}
+		slog.Log(ctx, level, msg, slog.Int("retry", i), slog.Any("err", err), slog.String("caller", callerFromContext(ctx)))
+	}
+}
+
+// WithOnRetryCallerLogging return a RetryOption that log a message on each retry,
+// including the location of the code that started the retry.
+// The log level will automatically be changed to error when reach DefaultMaxAttempts.
+func WithOnRetryCallerLogging(level slog.Level, msg string) RetryOption {
+	handler := NewOnRetryCallerLoggingHandler(level, msg)
+	return func(options *Options) {
+		WithOnRetry(handler)(options)
+		options.captureCaller = true
+	}
+}
+
+// OnSuccessHandler handler that will be called once when the operation succeeded,
+// with the number of attempts it took and the elapsed time.
+type OnSuccessHandler func(ctx context.Context, attempts int, elapsed time.Duration)
+
+// WithOnSuccess configure listener called once when the operation succeeded.
+func WithOnSuccess(handler OnSuccessHandler) RetryOption {
+	return func(options *Options) {
+		options.onSuccess = handler
+	}
+}
+
+// OnGiveUpHandler handler that will be called once when the retry ends in failure.
+type OnGiveUpHandler func(ctx context.Context, err error, attempts int)
+
+// WithOnGiveUp configure listener called exactly once when the retry ends in failure,
+// either because the attempts are exhausted, the error is not retryable, or the context is done.
+// It replaces the handler configured by WithGiveUpLogging.
+func WithOnGiveUp(handler OnGiveUpHandler, handlers ...OnGiveUpHandler) RetryOption {
+	handlers = append([]OnGiveUpHandler{handler}, handlers...)
+	return func(options *Options) {
+		options.onGiveUp = func(ctx context.Context, err error, attempts int, _ time.Duration) {
+			for i := range handlers {
+				handlers[i](ctx, err, attempts)
+			}
+		}
+	}
+}
+
+// WithGiveUpLogging return a RetryOption that log a message once when the retry ends in failure,
+// including the number of attempts, the elapsed time and the final error.
+// It replaces the handler configured by WithOnGiveUp.
+func WithGiveUpLogging(level slog.Level, msg string) RetryOption {
+	return func(options *Options) {
+		options.onGiveUp = func(ctx context.Context, err error, attempts int, elapsed time.Duration) {
+			slog.Log(ctx, level, msg, slog.Int("attempts", attempts), slog.Duration("elapsed", elapsed), slog.Any("err", err))
+		}
+	}
+}
+
 // RetryOption configure the Options.
 type RetryOption func(options *Options)
 
@@ -76,6 +199,70 @@ func WithAttempts(attempts int) RetryOption {
 	}
 }
 
+// WithMaxElapsedTime stop retrying once the total time spent, including attempts and backoff, would exceed the given duration,
+// regardless of the attempts remaining.
+// Useful to bound WithUnlimitedAttempts by time.
+func WithMaxElapsedTime(maxElapsedTime time.Duration) RetryOption {
+	return func(options *Options) {
+		options.maxElapsedTime = maxElapsedTime
+	}
+}
+
+// WithTimeout bound the total duration of the retry, by deriving a context with the given timeout
+// from the context of the retry, or from context.Background for Do and Get.
+// The operations passed to DoCtx and GetCtx receive the derived context,
+// while operations that don't take a context are not interrupted, but are not retried after the timeout.
+// Zero means no timeout.
+func WithTimeout(timeout time.Duration) RetryOption {
+	return func(options *Options) {
+		options.timeout = timeout
+	}
+}
+
+// WithContextDecorator derive the context passed to each attempt using the decorator,
+// called with the context of the attempt and the attempt number, starting from 1,
+// for example, to inject an idempotency key or trace baggage specific to the attempt.
+// Only the operations passed to DoCtx and GetCtx receive the decorated context, and the handlers of the attempt.
+func WithContextDecorator(decorator func(ctx context.Context, attempt int) context.Context) RetryOption {
+	return func(options *Options) {
+		options.contextDecorator = decorator
+	}
+}
+
+// WithAttemptTimeoutSchedule bound each attempt by a timeout, the nth timeout for the nth attempt,
+// then the last one for the following attempts, so early attempts fail fast, and later attempts give the dependency more time.
+// A timeout of 0 means no timeout for the attempt.
+// Only the operations passed to DoCtx and GetCtx receive the context with the timeout.
+// An attempt failing with context.DeadlineExceeded because its own timeout expired is retried,
+// as long as the context of the retry is not done, see WithTimeout to bound the whole retry.
+func WithAttemptTimeoutSchedule(timeouts ...time.Duration) RetryOption {
+	return func(options *Options) {
+		for _, timeout := range timeouts {
+			if timeout < 0 {
+				options.invalidate("negative attempt timeout %s", timeout)
+			}
+		}
+		options.attemptTimeouts = timeouts
+	}
+}
+
+// WithMaxTotalBackoff stop retrying once the cumulative backoff would exceed the given duration.
+// Unlike WithMaxElapsedTime, the time spent running the operation is not counted.
+func WithMaxTotalBackoff(maxTotalBackoff time.Duration) RetryOption {
+	return func(options *Options) {
+		options.maxTotalBackoff = maxTotalBackoff
+	}
+}
+
+// WithMinRemainingDeadline stop retrying if the context deadline leaves less than the given duration for the next attempt,
+// after waiting for the backoff, since an attempt that cannot finish in time only wastes resources.
+// It has no effect if the context has no deadline.
+func WithMinRemainingDeadline(d time.Duration) RetryOption {
+	return func(options *Options) {
+		options.minRemainingDeadline = d
+	}
+}
+
 // WithUnlimitedAttempts configure unlimited retries.
 func WithUnlimitedAttempts() RetryOption {
 	return func(options *Options) {
@@ -169,6 +356,21 @@ func WithNoRetryFor(err error, errs ...error) RetryOption {
 func WithBackoff(strategy backoff.Strategy) RetryOption {
 	return func(options *Options) {
 		options.backoffStrategy = strategy
+		options.backoffReset = nil
+		options.deadlineBackoff = nil
+		options.maxBackoff = 0
+	}
+}
+
+// WithStatefulBackoff configure a backoff.StatefulStrategy.
+// The strategy is reset after every successful operation,
+// so reusing the same Options or Retrier lets it keep state across calls until a success.
+func WithStatefulBackoff(strategy backoff.StatefulStrategy) RetryOption {
+	return func(options *Options) {
+		options.backoffStrategy = strategy.Next
+		options.backoffReset = strategy.Reset
+		options.deadlineBackoff = nil
+		options.maxBackoff = 0
 	}
 }
 
@@ -176,13 +378,22 @@ func WithBackoff(strategy backoff.Strategy) RetryOption {
 func WithNoBackoff() RetryOption {
 	return func(options *Options) {
 		options.backoffStrategy = nil
+		options.backoffReset = nil
+		options.deadlineBackoff = nil
+		options.maxBackoff = 0
 	}
 }
 
 // WithFixedBackoff fixed wait time between retries.
 func WithFixedBackoff(duration time.Duration) RetryOption {
 	return func(options *Options) {
+		if duration < 0 {
+			options.invalidate("negative backoff %s", duration)
+		}
 		options.backoffStrategy = backoff.NewFixedBackoff(duration)
+		options.backoffReset = nil
+		options.deadlineBackoff = nil
+		options.maxBackoff = 0
 	}
 }
 
@@ -190,7 +401,13 @@ func WithFixedBackoff(duration time.Duration) RetryOption {
 // Default jitter is half of the duration, if you need to customize this value, use WithBackoff with backoff.NewRandomBackoff.
 func WithRandomBackoff(duration time.Duration) RetryOption {
 	return func(options *Options) {
+		if duration < 0 {
+			options.invalidate("negative backoff %s", duration)
+		}
 		options.backoffStrategy = backoff.NewRandomBackoff(duration, duration/2)
+		options.backoffReset = nil
+		options.deadlineBackoff = nil
+		options.maxBackoff = 0
 	}
 }
 
@@ -198,7 +415,11 @@ func WithRandomBackoff(duration time.Duration) RetryOption {
 // Default multiplier is 2, if you need to customize this value, use WithBackoff with backoff.NewExponentialBackoff.
 func WithExponentialBackoff(initialBackoff time.Duration, maximumBackoff time.Duration) RetryOption {
 	return func(options *Options) {
+		options.validateExponential(initialBackoff, maximumBackoff)
 		options.backoffStrategy = backoff.NewExponentialRandomBackoff(initialBackoff, defaultMultiplier, maximumBackoff, initialBackoff/2)
+		options.backoffReset = nil
+		options.deadlineBackoff = nil
+		options.maxBackoff = 0
 	}
 }
 
@@ -207,7 +428,106 @@ func WithExponentialBackoff(initialBackoff time.Duration, maximumBackoff time.Du
 // The default jitter is half of the initialBackoff, if you need to customize this value, use WithBackoff with backoff.NewExponentialRandomBackoff.
 func WithExponentialRandomBackoff(initialBackoff time.Duration, maximumBackoff time.Duration) RetryOption {
 	return func(options *Options) {
+		options.validateExponential(initialBackoff, maximumBackoff)
 		options.backoffStrategy = backoff.NewExponentialBackoff(initialBackoff, defaultMultiplier, maximumBackoff)
+		options.backoffReset = nil
+		options.deadlineBackoff = nil
+		options.maxBackoff = 0
+	}
+}
+
+// WithDeadlineBackoff configure a backoff.DeadlineStrategy,
+// which receives the time left until the context deadline or the max elapsed time, and the number of attempts left.
+// See backoff.NewDeadlineSpread.
+func WithDeadlineBackoff(strategy backoff.DeadlineStrategy) RetryOption {
+	return func(options *Options) {
+		options.backoffStrategy = func(err error, i int) time.Duration {
+			return strategy(err, i, 0, 0)
+		}
+		options.backoffReset = nil
+		options.deadlineBackoff = strategy
+		options.maxBackoff = 0
+	}
+}
+
+// WithMultiplier multiply the wait time of the configured backoff strategy by multiplier^(n-1) for the nth retry.
+// Combined with WithFixedBackoff, it gives an exponential backoff with the given multiplier.
+// The multiplier applies on top of the configured strategy, so combined with an exponential strategy,
+// such as WithExponentialBackoff, both multipliers compound instead of being replaced,
+// use WithBackoff with backoff.NewExponentialBackoff to customize the multiplier of an exponential backoff.
+// The wait time stops growing at the maximum time.Duration.
+// It only applies to the strategy configured before it, and does nothing if backoff is disabled.
+func WithMultiplier(multiplier float64) RetryOption {
+	return func(options *Options) {
+		if !(multiplier > 0) {
+			options.invalidate("non-positive multiplier %g", multiplier)
+			return
+		}
+		options.decorateBackoff(func(strategy backoff.Strategy) backoff.Strategy {
+			return func(err error, i int) time.Duration {
+				d := strategy(err, i)
+				if d < 0 {
+					return d
+				}
+				scaled := float64(d) * math.Pow(multiplier, float64(i-1))
+				if scaled >= math.MaxInt64 {
+					return math.MaxInt64
+				}
+				return time.Duration(scaled)
+			}
+		})
+	}
+}
+
+// WithMaxBackoff limit the wait time of the configured backoff strategy.
+// It only applies to the strategy configured before it, and does nothing if backoff is disabled.
+// A maximum of 0 means no maximum.
+func WithMaxBackoff(maximumBackoff time.Duration) RetryOption {
+	return func(options *Options) {
+		if maximumBackoff < 0 {
+			options.invalidate("negative max backoff %s", maximumBackoff)
+			return
+		}
+		if maximumBackoff == 0 {
+			return
+		}
+		if options.backoffStrategy != nil && (options.maxBackoff == 0 || maximumBackoff < options.maxBackoff) {
+			options.maxBackoff = maximumBackoff
+		}
+		options.decorateBackoff(func(strategy backoff.Strategy) backoff.Strategy {
+			return backoff.Cap(strategy, maximumBackoff)
+		})
+	}
+}
+
+// WithJitter add random jitter to the wait time of the configured backoff strategy.
+// It only applies to the strategy configured before it, and does nothing if backoff is disabled or jitter is 0.
+// See backoff.NewBackoffWithJitter.
+func WithJitter(jitter time.Duration) RetryOption {
+	return func(options *Options) {
+		if jitter < 0 {
+			options.invalidate("negative jitter %s", jitter)
+		}
+		if jitter <= 0 {
+			return
+		}
+		options.decorateBackoff(func(strategy backoff.Strategy) backoff.Strategy {
+			return backoff.NewBackoffWithJitter(strategy, jitter)
+		})
+	}
+}
+
+// WithRetryAfterHints wait for the backoff hinted by the error instead of the configured backoff strategy,
+// when the error implements backoff.RetryAfterHint, for example, an error created by WithRetryAfter.
+// The hint is still limited by the WithMaxBackoff configured before it.
+// It only applies to the strategy configured before it, and does nothing if backoff is disabled.
+// See backoff.NewHintAwareBackoff.
+func WithRetryAfterHints() RetryOption {
+	return func(options *Options) {
+		maxBackoff := options.maxBackoff
+		options.decorateBackoff(func(strategy backoff.Strategy) backoff.Strategy {
+			return backoff.Cap(backoff.NewHintAwareBackoff(strategy), maxBackoff)
+		})
 	}
 }
 
@@ -228,6 +548,64 @@ func WithOnRetry(handler OnRetryHandler, handlers ...OnRetryHandler) RetryOption
 	}
 }
 
+// WithParallelism limit the number of operations running concurrently in helpers that perform multiple operations, such as All.
+// Zero means no limit. It has no effect on Do and Get.
+func WithParallelism(n int) RetryOption {
+	return func(options *Options) {
+		options.parallelism = n
+	}
+}
+
+// WithAsyncHandlers run the OnRetry and give-up handlers in a background goroutine,
+// so slow handlers do not delay the next attempt.
+// Up to queueSize pending handler calls are kept per retry loop, OnRetry calls are dropped when the queue is full,
+// while the give-up and success handlers wait for room in the queue, so they are never lost.
+func WithAsyncHandlers(queueSize int) RetryOption {
+	return func(options *Options) {
+		options.asyncQueueSize = queueSize
+	}
+}
+
+// WithCollectErrors keep the error of every failed attempt,
+// so the error returned when the retry ends in failure is an AttemptErrors containing all of them.
+func WithCollectErrors() RetryOption {
+	return func(options *Options) {
+		options.collectErrors = true
+	}
+}
+
+// WithClock set the Clock used to measure time and wait for backoff.
+// Useful in tests to run retries with backoff instantly using a fake clock.
+func WithClock(clock Clock) RetryOption {
+	return func(options *Options) {
+		options.clock = clock
+	}
+}
+
+// WithRecoverPanic recover panics in the operation, converting them into a PanicError.
+// The PanicError is retried according to the matchers like any other error.
+func WithRecoverPanic() RetryOption {
+	return func(options *Options) {
+		options.recoverPanic = true
+	}
+}
+
+// WithOnRetryBeforeBackoff call the OnRetry handlers as soon as a retry is decided, before waiting for the backoff.
+// By default, they are called after the backoff, right before the next attempt.
+func WithOnRetryBeforeBackoff() RetryOption {
+	return func(options *Options) {
+		options.onRetryBeforeBackoff = true
+	}
+}
+
+// WithOnRetryInfo configure listener on each retry, that receives the upcoming backoff and the elapsed time.
+// Unlike WithOnRetry, the handler is called as soon as the retry is decided, before waiting for the backoff.
+func WithOnRetryInfo(handler OnRetryInfoHandler) RetryOption {
+	return func(options *Options) {
+		options.onRetryInfo = handler
+	}
+}
+
 // WithRetryOnContextError enable retry when the operation returns a context.DeadlineExceeded or context.Canceled.
 // It still doesn't retry when the error comes from the Options context.
 func WithRetryOnContextError() RetryOption {
@@ -236,10 +614,94 @@ func WithRetryOnContextError() RetryOption {
 	}
 }
 
+// Validate report nonsensical configurations, that otherwise fail silently or behave unexpectedly at runtime,
+// such as negative attempts, or unlimited attempts retrying every error without backoff.
+// The returned error wraps ErrInvalidOptions, and joins every problem found.
+func (o Options) Validate() error {
+	errs := append([]error(nil), o.invalid...)
+	add := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: "+format, append([]any{ErrInvalidOptions}, args...)...))
+	}
+	if o.maxAttempts < 0 {
+		add("negative attempts %d", o.maxAttempts)
+	}
+	if o.maxElapsedTime < 0 {
+		add("negative max elapsed time %s", o.maxElapsedTime)
+	}
+	if o.maxTotalBackoff < 0 {
+		add("negative max total backoff %s", o.maxTotalBackoff)
+	}
+	if o.minRemainingDeadline < 0 {
+		add("negative min remaining deadline %s", o.minRemainingDeadline)
+	}
+	if o.parallelism < 0 {
+		add("negative parallelism %d", o.parallelism)
+	}
+	if o.asyncQueueSize < 0 {
+		add("negative async handlers queue size %d", o.asyncQueueSize)
+	}
+	if o.backoffStrategy == nil && o.maxAttempts == 0 && o.maxElapsedTime == 0 && o.matcher == nil {
+		add("unlimited attempts without backoff and matcher would busy-loop")
+	}
+	return errors.Join(errs...)
+}
+
+// invalidate record a problem found while applying an option, reported by Validate.
+func (o *Options) invalidate(format string, args ...any) {
+	err := fmt.Errorf("%w: "+format, append([]any{ErrInvalidOptions}, args...)...)
+	o.invalid = append(o.invalid[:len(o.invalid):len(o.invalid)], err)
+}
+
+func (o *Options) validateExponential(initialBackoff time.Duration, maximumBackoff time.Duration) {
+	if initialBackoff < 0 {
+		o.invalidate("negative initial backoff %s", initialBackoff)
+	}
+	if maximumBackoff < 0 {
+		o.invalidate("negative max backoff %s", maximumBackoff)
+	}
+	if maximumBackoff > 0 && maximumBackoff < initialBackoff {
+		o.invalidate("max backoff %s is smaller than initial backoff %s", maximumBackoff, initialBackoff)
+	}
+}
+
+// decorateBackoff wrap the configured backoff strategy, including the deadline strategy if any.
+// It does nothing if backoff is disabled.
+func (o *Options) decorateBackoff(decorate func(strategy backoff.Strategy) backoff.Strategy) {
+	if o.backoffStrategy == nil {
+		return
+	}
+	o.backoffStrategy = decorate(o.backoffStrategy)
+	if strategy := o.deadlineBackoff; strategy != nil {
+		o.deadlineBackoff = func(err error, i int, remaining time.Duration, attemptsLeft int) time.Duration {
+			return decorate(func(err error, i int) time.Duration {
+				return strategy(err, i, remaining, attemptsLeft)
+			})(err, i)
+		}
+	}
+}
+
+func (o Options) getClock() Clock {
+	if o.clock == nil {
+		return realClock{}
+	}
+	return o.clock
+}
+
 func (o Options) matchError(err error) bool {
+	if IsUnrecoverable(err) {
+		return false
+	}
+	if IsForceRetryable(err) {
+		return true
+	}
 	if o.excludedMatcher != nil && o.excludedMatcher(err) {
 		return false
 	}
+	if o.matcher == nil {
+		if retryable, ok := classify(err); ok {
+			return retryable
+		}
+	}
 	if o.skipContextError {
 		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
 			return false
@@ -251,6 +713,93 @@ func (o Options) matchError(err error) bool {
 	return o.matcher(err)
 }
 
+// LogValue implements slog.LogValuer, so an Options can be logged as a structured group.
+func (o Options) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("attempts", o.maxAttempts),
+		slog.Bool("backoff", o.backoffStrategy != nil),
+		slog.Bool("retryIf", o.matcher != nil),
+		slog.Bool("noRetryIf", o.excludedMatcher != nil),
+		slog.Bool("retryOnContextError", !o.skipContextError),
+		slog.Bool("onRetry", o.onRetry != nil),
+		slog.Duration("maxBackoff", o.maxBackoff),
+		slog.Duration("maxElapsedTime", o.maxElapsedTime),
+		slog.Duration("maxTotalBackoff", o.maxTotalBackoff),
+		slog.Duration("timeout", o.timeout),
+		slog.Duration("minRemainingDeadline", o.minRemainingDeadline),
+		slog.Bool("attemptTimeout", len(o.attemptTimeouts) > 0),
+		slog.Bool("recoverPanic", o.recoverPanic),
+		slog.Bool("budget", o.budget != nil),
+		slog.Bool("breaker", o.breaker != nil),
+		slog.Bool("limiter", o.limiter != nil),
+		slog.Bool("concurrencyLimit", o.bulkhead != nil),
+		slog.Bool("dynamic", o.dynamic != nil),
+	)
+}
+
+// Attempts return the maximum number of runs, 0 means unlimited.
+func (o Options) Attempts() int {
+	return o.maxAttempts
+}
+
+// HasBackoff return whether a backoff strategy is configured.
+func (o Options) HasBackoff() bool {
+	return o.backoffStrategy != nil
+}
+
+// HasRetryIf return whether a matcher is configured to select the errors to retry.
+func (o Options) HasRetryIf() bool {
+	return o.matcher != nil
+}
+
+// HasNoRetryIf return whether a matcher is configured to exclude errors from retry.
+func (o Options) HasNoRetryIf() bool {
+	return o.excludedMatcher != nil
+}
+
+// RetryOnContextError return whether context.DeadlineExceeded and context.Canceled returned by the operation are retried.
+func (o Options) RetryOnContextError() bool {
+	return !o.skipContextError
+}
+
+// MaxElapsedTime return the maximum total time spent retrying, 0 means unlimited.
+func (o Options) MaxElapsedTime() time.Duration {
+	return o.maxElapsedTime
+}
+
+// MinRemainingDeadline return the minimum remaining context deadline required to retry.
+func (o Options) MinRemainingDeadline() time.Duration {
+	return o.minRemainingDeadline
+}
+
+// Parallelism return the maximum number of operations running concurrently in helpers such as All, 0 means no limit.
+func (o Options) Parallelism() int {
+	return o.parallelism
+}
+
+// Context return the context configured by WithContext, or nil.
+func (o Options) Context() context.Context {
+	return o.context
+}
+
+// Clone return a copy of the options.
+// Changes to the copy using With do not affect the original.
+// The handlers and the backoff strategy are shared, including the state of a stateful backoff strategy.
+func (o Options) Clone() Options {
+	o.invalid = o.invalid[:len(o.invalid):len(o.invalid)]
+	return o
+}
+
+// With return a copy of the options with the given options applied,
+// so a shared policy can be customized per call-site without modifying it.
+func (o Options) With(retryOptions ...RetryOption) Options {
+	c := o.Clone()
+	for _, opt := range retryOptions {
+		opt(&c)
+	}
+	return c
+}
+
 // WithOptions copy all the specified Options value into this options.
 // Useful if you have a global Options somewhere and want to customize it for local use case,
 // otherwise just use the DoWithOptions instead.
@@ -266,11 +815,10 @@ func WithOptions(opt Options) RetryOption {
 // - 200ms backoff
 // - does not retry on context error, retry on every other error.
 func NewOptions(options ...RetryOption) Options {
-	otp := Options{
-		backoffStrategy:  backoff.NewFixedBackoff(DefaultBackoff),
-		maxAttempts:      DefaultMaxAttempts,
-		skipContextError: true,
+	if len(options) == 0 {
+		return defaultOptions()
 	}
+	otp := defaultOptions()
 	for _, o := range options {
 		o(&otp)
 	}
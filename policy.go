@@ -0,0 +1,194 @@
+package try
+
+import (
+	"errors"
+	"fmt"
+	"github.com/mawngo/go-try/backoff"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidPolicy is returned by ParsePolicy when the policy string is malformed.
+var ErrInvalidPolicy = errors.New("invalid policy")
+
+// ParsePolicy parse a one-line retry policy into Options, starting from the defaults of NewOptions.
+// The policy is a space separated list of key=value, for example:
+//
+//	attempts=5 backoff=exponential(200ms,2,10s) jitter=20% max-backoff=5s max-elapsed=1m
+//
+// Supported keys:
+//   - attempts: the maximum number of runs, or "unlimited".
+//   - backoff: none, fixed(d), exponential(initial,multiplier,max), incremental(initial,increment,max)
+//     or decorrelated(base,max). A max of 0 means no maximum.
+//   - jitter: the maximum random jitter added to the backoff, either a duration or a percentage of the backoff.
+//   - max-backoff: limit the backoff, applied after the jitter.
+//   - max-elapsed: the maximum total time spent retrying.
+//
+// The returned Options are validated using Options.Validate.
+func ParsePolicy(policy string) (Options, error) {
+	var retryOptions []RetryOption
+	var jitter, maxBackoff RetryOption
+	for _, field := range strings.Fields(policy) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return Options{}, fmt.Errorf("%w: expected key=value, got %q", ErrInvalidPolicy, field)
+		}
+		switch key {
+		case "attempts":
+			if value == "unlimited" {
+				retryOptions = append(retryOptions, WithUnlimitedAttempts())
+				continue
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return Options{}, fmt.Errorf("%w: attempts: %w", ErrInvalidPolicy, err)
+			}
+			retryOptions = append(retryOptions, WithAttempts(n))
+		case "backoff":
+			opt, err := parsePolicyBackoff(value)
+			if err != nil {
+				return Options{}, err
+			}
+			retryOptions = append(retryOptions, opt)
+		case "jitter":
+			if percent, ok := strings.CutSuffix(value, "%"); ok {
+				f, err := strconv.ParseFloat(percent, 64)
+				if err != nil || f < 0 {
+					return Options{}, fmt.Errorf("%w: jitter: invalid percentage %q", ErrInvalidPolicy, value)
+				}
+				jitter = func(options *Options) {
+					options.decorateBackoff(func(strategy backoff.Strategy) backoff.Strategy {
+						return backoff.NewBackoffWithRelativeJitter(strategy, f/100)
+					})
+				}
+				continue
+			}
+			d, err := parsePolicyDuration(key, value)
+			if err != nil {
+				return Options{}, err
+			}
+			jitter = WithJitter(d)
+		case "max-backoff":
+			d, err := parsePolicyDuration(key, value)
+			if err != nil {
+				return Options{}, err
+			}
+			maxBackoff = WithMaxBackoff(d)
+		case "max-elapsed":
+			d, err := parsePolicyDuration(key, value)
+			if err != nil {
+				return Options{}, err
+			}
+			retryOptions = append(retryOptions, WithMaxElapsedTime(d))
+		default:
+			return Options{}, fmt.Errorf("%w: unknown key %q", ErrInvalidPolicy, key)
+		}
+	}
+	// Decorators apply to the configured backoff regardless of their position in the policy.
+	if jitter != nil {
+		retryOptions = append(retryOptions, jitter)
+	}
+	if maxBackoff != nil {
+		retryOptions = append(retryOptions, maxBackoff)
+	}
+	options := NewOptions(retryOptions...)
+	if err := options.Validate(); err != nil {
+		return Options{}, err
+	}
+	return options, nil
+}
+
+func parsePolicyBackoff(value string) (RetryOption, error) {
+	name, args, err := parsePolicyCall(value)
+	if err != nil {
+		return nil, err
+	}
+	durations := func(n int) ([]time.Duration, error) {
+		if len(args) != n {
+			return nil, fmt.Errorf("%w: backoff %s expects %d arguments, got %d", ErrInvalidPolicy, name, n, len(args))
+		}
+		res := make([]time.Duration, n)
+		for i, arg := range args {
+			d, err := parsePolicyDuration("backoff "+name, arg)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = d
+		}
+		return res, nil
+	}
+	switch name {
+	case "none":
+		if _, err := durations(0); err != nil {
+			return nil, err
+		}
+		return WithNoBackoff(), nil
+	case "fixed":
+		d, err := durations(1)
+		if err != nil {
+			return nil, err
+		}
+		return WithFixedBackoff(d[0]), nil
+	case "exponential":
+		if len(args) != 3 {
+			return nil, fmt.Errorf("%w: backoff %s expects 3 arguments, got %d", ErrInvalidPolicy, name, len(args))
+		}
+		multiplier, err := strconv.ParseFloat(args[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: backoff %s: invalid multiplier %q", ErrInvalidPolicy, name, args[1])
+		}
+		args = []string{args[0], args[2]}
+		d, err := durations(2)
+		if err != nil {
+			return nil, err
+		}
+		return func(options *Options) {
+			options.validateExponential(d[0], d[1])
+			if multiplier <= 0 {
+				options.invalidate("non-positive multiplier %g", multiplier)
+			}
+			options.backoffStrategy = backoff.NewExponentialBackoff(d[0], multiplier, d[1])
+			options.backoffReset = nil
+			options.deadlineBackoff = nil
+			options.maxBackoff = 0
+		}, nil
+	case "incremental":
+		d, err := durations(3)
+		if err != nil {
+			return nil, err
+		}
+		return WithBackoff(backoff.NewIncrementalBackoff(d[0], d[1], d[2])), nil
+	case "decorrelated":
+		d, err := durations(2)
+		if err != nil {
+			return nil, err
+		}
+		return WithStatefulBackoff(backoff.NewDecorrelatedJitterBackoff(d[0], d[1])), nil
+	}
+	return nil, fmt.Errorf("%w: unknown backoff %q", ErrInvalidPolicy, name)
+}
+
+// parsePolicyCall split "name(a,b)" into its name and arguments, a bare "name" has no arguments.
+func parsePolicyCall(value string) (string, []string, error) {
+	name, rest, ok := strings.Cut(value, "(")
+	if !ok {
+		return value, nil, nil
+	}
+	rest, ok = strings.CutSuffix(rest, ")")
+	if !ok {
+		return "", nil, fmt.Errorf("%w: missing closing parenthesis in %q", ErrInvalidPolicy, value)
+	}
+	if rest == "" {
+		return name, nil, nil
+	}
+	return name, strings.Split(rest, ","), nil
+}
+
+func parsePolicyDuration(key string, value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s: %w", ErrInvalidPolicy, key, err)
+	}
+	return d, nil
+}
@@ -0,0 +1,63 @@
+package try
+
+import (
+	"sync"
+)
+
+// Pool is a bounded worker pool that performs submitted operations with retry.
+type Pool struct {
+	tasks     chan func() error
+	options   Options
+	onFailure func(err error)
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// NewPool create a Pool with the given number of workers.
+// See NewPoolWithOptions.
+func NewPool(workers int, onFailure func(err error), retryOptions ...RetryOption) *Pool {
+	option := NewOptions(retryOptions...)
+	return NewPoolWithOptions(workers, onFailure, option)
+}
+
+// NewPoolWithOptions create a Pool with the given number of workers.
+// Each submitted operation is performed by a worker and retried based on the options.
+// Errors of operations that still failed are reported to onFailure, which may be nil.
+// The pool has at least one worker.
+func NewPoolWithOptions(workers int, onFailure func(err error), options Options) *Pool {
+	workers = max(workers, 1)
+	p := &Pool{
+		tasks:     make(chan func() error),
+		options:   options,
+		onFailure: onFailure,
+	}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for op := range p.tasks {
+		err := DoWithOptions(op, p.options)
+		if err != nil && p.onFailure != nil {
+			p.onFailure(err)
+		}
+	}
+}
+
+// Submit queue the operation, blocking until a worker is available.
+// Submit must not be called after Close.
+func (p *Pool) Submit(op func() error) {
+	p.tasks <- op
+}
+
+// Close stop accepting operations and wait for the submitted operations to finish.
+func (p *Pool) Close() {
+	p.closeOnce.Do(func() {
+		close(p.tasks)
+	})
+	p.wg.Wait()
+}
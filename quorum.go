@@ -0,0 +1,67 @@
+package try
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrNoQuorum is returned when not enough sources agree on a value.
+var ErrNoQuorum = errors.New("no quorum")
+
+// ErrInvalidQuorum is returned when the quorum is not between 1 and the number of sources.
+var ErrInvalidQuorum = errors.New("invalid quorum")
+
+// GetQuorum performs all sources concurrently, and return the value that at least n of them agree on.
+// Values are compared using equal, sources that return an error are not counted.
+// Based on the retryOptions, the whole read is retried until a quorum is reached.
+// See GetQuorumWithOptions.
+func GetQuorum[T any](sources []func() (T, error), n int, equal func(a T, b T) bool, retryOptions ...RetryOption) (T, error) {
+	option := NewOptions(retryOptions...)
+	return GetQuorumWithOptions(sources, n, equal, option)
+}
+
+// GetQuorumWithOptions performs all sources concurrently, and return the value that at least n of them agree on.
+// If no quorum is reached, the read fails with ErrNoQuorum joined with the errors of the sources,
+// and is retried based on the options.
+// If n is not between 1 and the number of sources, it return ErrInvalidQuorum without performing the sources.
+func GetQuorumWithOptions[T any](sources []func() (T, error), n int, equal func(a T, b T) bool, options Options) (T, error) {
+	if n <= 0 || n > len(sources) {
+		var empty T
+		return empty, fmt.Errorf("%w: %d of %d sources", ErrInvalidQuorum, n, len(sources))
+	}
+	return GetWithOptions(func() (T, error) {
+		return readQuorum(sources, n, equal)
+	}, options)
+}
+
+func readQuorum[T any](sources []func() (T, error), n int, equal func(a T, b T) bool) (T, error) {
+	values := make([]T, len(sources))
+	errs := make([]error, len(sources))
+	wg := sync.WaitGroup{}
+	for i := range sources {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			values[i], errs[i] = sources[i]()
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range values {
+		if errs[i] != nil {
+			continue
+		}
+		cnt := 0
+		for j := range values {
+			if errs[j] == nil && equal(values[i], values[j]) {
+				cnt++
+			}
+		}
+		if cnt >= n {
+			return values[i], nil
+		}
+	}
+	var empty T
+	return empty, errors.Join(append([]error{ErrNoQuorum}, errs...)...)
+}
@@ -0,0 +1,52 @@
+package try
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+var policies = struct {
+	sync.RWMutex
+	options map[string]Options
+}{options: make(map[string]Options)}
+
+// RegisterPolicy register the options as a named policy, process-wide,
+// so canonical policies can be defined once and referenced by name using Policy.
+// Registering a name again replaces the previous policy.
+func RegisterPolicy(name string, options Options) {
+	policies.Lock()
+	defer policies.Unlock()
+	policies.options[name] = options.Clone()
+}
+
+// Policy return the options of the named policy registered using RegisterPolicy.
+// Panics if the policy is not registered, see LookupPolicy for a non panicking variant.
+func Policy(name string) Options {
+	options, ok := LookupPolicy(name)
+	if !ok {
+		panic(fmt.Sprintf("try: unknown policy %q", name))
+	}
+	return options
+}
+
+// LookupPolicy return the options of the named policy registered using RegisterPolicy,
+// ok is false if the policy is not registered.
+func LookupPolicy(name string) (options Options, ok bool) {
+	policies.RLock()
+	defer policies.RUnlock()
+	options, ok = policies.options[name]
+	return options.Clone(), ok
+}
+
+// Policies return the sorted names of the registered policies.
+func Policies() []string {
+	policies.RLock()
+	defer policies.RUnlock()
+	names := make([]string, 0, len(policies.options))
+	for name := range policies.options {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,65 @@
+package try
+
+import (
+	"time"
+)
+
+// Report describes every attempt made while performing an operation.
+type Report struct {
+	// Attempts contains the attempts in the order they were made.
+	Attempts []AttemptReport
+	// Elapsed is the total time spent, including attempts and backoff.
+	Elapsed time.Duration
+	// TotalBackoff is the total backoff applied between attempts.
+	TotalBackoff time.Duration
+}
+
+// AttemptReport describes a single attempt.
+type AttemptReport struct {
+	// Err is the error returned by the attempt, nil if it succeeded.
+	Err error
+	// Start is the time the attempt started.
+	Start time.Time
+	// Duration is the time the attempt took.
+	Duration time.Duration
+	// Backoff is the backoff applied after the attempt, zero for the last attempt.
+	Backoff time.Duration
+}
+
+// DoReport performs the given operation, and return a Report of the attempts.
+// See DoReportWithOptions.
+func DoReport(op func() error, retryOptions ...RetryOption) (Report, error) {
+	option := NewOptions(retryOptions...)
+	return DoReportWithOptions(op, option)
+}
+
+// DoReportWithOptions performs the given operation, and return a Report of the attempts.
+// See DoWithOptions.
+func DoReportWithOptions(op func() error, options Options) (Report, error) {
+	report := Report{}
+	options.report = &report
+	clock := options.getClock()
+	start := clock.Now()
+	err := DoWithOptions(op, options)
+	report.Elapsed = clock.Now().Sub(start)
+	return report, err
+}
+
+// GetReport performs the given operation, and return the result and a Report of the attempts.
+// See GetReportWithOptions.
+func GetReport[T any](op func() (T, error), retryOptions ...RetryOption) (T, Report, error) {
+	option := NewOptions(retryOptions...)
+	return GetReportWithOptions(op, option)
+}
+
+// GetReportWithOptions performs the given operation, and return the result and a Report of the attempts.
+// See GetWithOptions.
+func GetReportWithOptions[T any](op func() (T, error), options Options) (T, Report, error) {
+	report := Report{}
+	options.report = &report
+	clock := options.getClock()
+	start := clock.Now()
+	v, err := GetWithOptions(op, options)
+	report.Elapsed = clock.Now().Sub(start)
+	return v, report, err
+}
@@ -0,0 +1,53 @@
+package try
+
+import (
+	"context"
+)
+
+// Retrier performs operations using a pre-built Options.
+// The options are applied once when the Retrier is created, including composing the matchers and handlers,
+// so it is cheaper to reuse a Retrier than passing the same RetryOption to every call.
+// A Retrier is safe for concurrent use.
+type Retrier struct {
+	options Options
+}
+
+// New create a Retrier.
+// See NewOptions for defaults.
+func New(retryOptions ...RetryOption) *Retrier {
+	return NewWithOptions(NewOptions(retryOptions...))
+}
+
+// NewWithOptions create a Retrier using the given options.
+func NewWithOptions(options Options) *Retrier {
+	return &Retrier{options: options}
+}
+
+// Options return the options of this Retrier.
+func (r *Retrier) Options() Options {
+	return r.options
+}
+
+// Do performs the given operation.
+// See DoWithOptions.
+func (r *Retrier) Do(op func() error) error {
+	return DoWithOptions(op, r.options)
+}
+
+// DoCtx performs the given operation, passing it the context of the retry.
+// See DoCtxWithOptions.
+func (r *Retrier) DoCtx(ctx context.Context, op func(ctx context.Context) error) error {
+	return DoCtxWithOptions(ctx, op, r.options)
+}
+
+// GetWithRetrier performs the given operation using the Retrier, and return the result.
+// See GetWithOptions.
+func GetWithRetrier[T any](op func() (T, error), r *Retrier) (T, error) {
+	return GetWithOptions(op, r.options)
+}
+
+// GetCtxWithRetrier performs the given operation using the Retrier, passing it the context of the retry, and return the result.
+// See GetCtxWithOptions.
+func GetCtxWithRetrier[T any](ctx context.Context, op func(ctx context.Context) (T, error), r *Retrier) (T, error) {
+	return GetCtxWithOptions(ctx, op, r.options)
+}
@@ -0,0 +1,273 @@
+package try
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Scheduler retries tasks in the background over long periods, for example, to eventually deliver a webhook.
+// Unlike Pool, no goroutine is blocked while waiting for the backoff, the next attempt is scheduled using a timer.
+// Tasks scheduled before Start wait for it. Create it using NewScheduler.
+type Scheduler struct {
+	mu      sync.Mutex
+	started bool
+	stopped bool
+	pending []*Task
+	tasks   map[*Task]struct{}
+	wg      sync.WaitGroup
+}
+
+// Task is a task scheduled by a Scheduler.
+type Task struct {
+	s         *Scheduler
+	op        func(ctx context.Context) error
+	options   Options
+	onDone    func(err error)
+	ctx       context.Context
+	cancel    context.CancelFunc
+	mu        sync.Mutex
+	timer     *time.Timer
+	running   bool
+	attempts  int
+	state     retryState
+	done      chan struct{}
+	err       error
+	closeOnce sync.Once
+}
+
+// NewScheduler create a Scheduler.
+func NewScheduler() *Scheduler {
+	return &Scheduler{tasks: make(map[*Task]struct{})}
+}
+
+// Start running the scheduled tasks.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	if s.started || s.stopped {
+		s.mu.Unlock()
+		return
+	}
+	s.started = true
+	pending := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+	for _, t := range pending {
+		t.schedule(0)
+	}
+}
+
+// Stop cancel every task, and wait for the running attempts to finish.
+// The tasks complete with context.Canceled, and tasks cannot be scheduled after Stop.
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	s.stopped = true
+	tasks := make([]*Task, 0, len(s.tasks))
+	for t := range s.tasks {
+		tasks = append(tasks, t)
+	}
+	s.mu.Unlock()
+	for _, t := range tasks {
+		t.Cancel()
+	}
+	s.wg.Wait()
+}
+
+// Schedule retry the operation in the background.
+// See ScheduleWithOptions.
+func (s *Scheduler) Schedule(op func(ctx context.Context) error, onDone func(err error), retryOptions ...RetryOption) *Task {
+	option := NewOptions(retryOptions...)
+	return s.ScheduleWithOptions(op, onDone, option)
+}
+
+// ScheduleWithOptions retry the operation in the background based on the options,
+// and call onDone, which may be nil, with the outcome once the operation succeeded or the retry gave up.
+// The retry decision is the same as DoCtxWithOptions, but the handlers are not called.
+// A panic never crashes the Scheduler: the task completes with a *PanicError, or retries it when WithRecoverPanic is set.
+// The Limiter, the concurrency limit and the timeout would block or outlive the timer,
+// and the timer cannot follow a Clock configured by WithClock, so tasks using them complete immediately with ErrInvalidOptions.
+// The task completes as soon as the context of the options is done, even while waiting for the backoff.
+// If the Scheduler is stopped, the task completes immediately with context.Canceled.
+func (s *Scheduler) ScheduleWithOptions(op func(ctx context.Context) error, onDone func(err error), options Options) *Task {
+	ctx := options.context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	options = applyContextOptions(ctx, options)
+	t := &Task{s: s, op: op, options: options, onDone: onDone, done: make(chan struct{})}
+	t.ctx, t.cancel = context.WithCancel(ctx)
+	context.AfterFunc(t.ctx, t.stop)
+	t.state = retryState{start: options.getClock().Now()}
+	t.state.budget, _ = ctx.Value(budgetKey{}).(*retryBudget)
+
+	if err := options.schedulerUnsupported(); err != nil {
+		t.finish(err)
+		return t
+	}
+
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		t.finish(context.Canceled)
+		return t
+	}
+	s.tasks[t] = struct{}{}
+	s.wg.Add(1)
+	started := s.started
+	if !started {
+		s.pending = append(s.pending, t)
+	}
+	s.mu.Unlock()
+	if started {
+		t.schedule(0)
+	}
+	return t
+}
+
+// Cancel stop retrying the task, it completes with context.Canceled unless an attempt is running.
+// A running attempt receives a canceled context, and the task is not retried after it.
+func (t *Task) Cancel() {
+	t.cancel()
+	t.stop()
+}
+
+// stop complete the task once its context is done, unless an attempt is running or about to run.
+func (t *Task) stop() {
+	t.mu.Lock()
+	if t.running || (t.timer != nil && !t.timer.Stop()) {
+		t.mu.Unlock()
+		return
+	}
+	err := combineErr(t.ctx.Err(), t.state.lastErr)
+	t.mu.Unlock()
+	t.finish(err)
+}
+
+// Done return a channel that is closed once the task completed.
+func (t *Task) Done() <-chan struct{} {
+	return t.done
+}
+
+// Err wait for the task to complete, and return its outcome.
+func (t *Task) Err() error {
+	<-t.done
+	return t.err
+}
+
+// schedule the next attempt after d.
+func (t *Task) schedule(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timer = time.AfterFunc(d, t.run)
+}
+
+func (t *Task) run() {
+	t.mu.Lock()
+	if t.ctx.Err() != nil {
+		err := combineErr(t.ctx.Err(), t.state.lastErr)
+		t.mu.Unlock()
+		t.finish(err)
+		return
+	}
+	t.running = true
+	t.mu.Unlock()
+
+	d, retry, err := t.attempt()
+
+	t.mu.Lock()
+	t.running = false
+	switch {
+	case !retry:
+	case t.ctx.Err() != nil:
+		err = combineErr(t.ctx.Err(), t.state.lastErr)
+	default:
+		t.timer = time.AfterFunc(d, t.run)
+		t.mu.Unlock()
+		return
+	}
+	t.mu.Unlock()
+	t.finish(err)
+}
+
+// attempt run the operation once, and return the backoff before the next attempt,
+// or false with the outcome of the task.
+func (t *Task) attempt() (time.Duration, bool, error) {
+	o := &t.options
+	clock := o.getClock()
+	if o.breaker != nil && !o.breaker.Allow() {
+		if t.attempts == 0 {
+			return 0, false, ErrCircuitOpen
+		}
+		return 0, false, newRetryError(ErrCircuitOpen, t.state.lastErr, t.attempts, clock.Now().Sub(t.state.start))
+	}
+	t.attempts++
+	ctx := context.WithValue(t.ctx, attemptKey{}, attemptValue{attempt: t.attempts, lastErr: t.state.lastErr})
+	if o.contextDecorator != nil {
+		ctx = o.contextDecorator(ctx, t.attempts)
+	}
+	opCtx := ctx
+	var cancelAttempt context.CancelFunc
+	if timeout := o.attemptTimeout(t.attempts); timeout > 0 {
+		opCtx, cancelAttempt = context.WithTimeout(ctx, timeout)
+	}
+	// Panics are always recovered, as nothing could recover them on the timer goroutine.
+	_, err := callAttempt(opCtx, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, t.op(ctx)
+	}, true, nil, nil)
+	if cancelAttempt != nil {
+		err = attemptTimedOut(t.ctx, opCtx, err)
+		cancelAttempt()
+	}
+	if err == nil {
+		o.recordSuccess()
+		return 0, false, nil
+	}
+	var panicErr *PanicError
+	if !o.recoverPanic && errors.As(err, &panicErr) {
+		if o.breaker != nil {
+			o.breaker.Release()
+		}
+		return 0, false, err
+	}
+	d, giveUp := o.next(t.ctx, clock, &t.state, err, t.attempts)
+	if giveUp != nil {
+		return 0, false, giveUp
+	}
+	return d, true, err
+}
+
+// schedulerUnsupported return an error if the options use a feature the Scheduler cannot honor.
+func (o *Options) schedulerUnsupported() error {
+	switch {
+	case o.limiter != nil:
+		return fmt.Errorf("%w: the Scheduler does not support a Limiter", ErrInvalidOptions)
+	case o.bulkhead != nil:
+		return fmt.Errorf("%w: the Scheduler does not support a concurrency limit", ErrInvalidOptions)
+	case o.timeout > 0:
+		return fmt.Errorf("%w: the Scheduler does not support a timeout, use a context with a deadline", ErrInvalidOptions)
+	case o.clock != nil:
+		return fmt.Errorf("%w: the Scheduler waits using timers and does not support a Clock", ErrInvalidOptions)
+	}
+	return nil
+}
+
+// finish complete the task, only the first call has effect.
+func (t *Task) finish(err error) {
+	t.closeOnce.Do(func() {
+		t.err = err
+		t.cancel()
+		close(t.done)
+		if t.onDone != nil {
+			t.onDone(err)
+		}
+		t.s.mu.Lock()
+		_, ok := t.s.tasks[t]
+		delete(t.s.tasks, t)
+		t.s.mu.Unlock()
+		if ok {
+			t.s.wg.Done()
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package try
+
+import (
+	"errors"
+)
+
+// ErrNotStable is returned when the value is still changing.
+var ErrNotStable = errors.New("value not stable")
+
+// GetStable performs the given operation repeatedly, and return the value once it stays the same for k consecutive reads.
+// See GetStableWithOptions.
+func GetStable[T any](op func() (T, error), k int, equal func(a T, b T) bool, retryOptions ...RetryOption) (T, error) {
+	option := NewOptions(retryOptions...)
+	return GetStableWithOptions(op, k, equal, option)
+}
+
+// GetStableWithOptions performs the given operation repeatedly, and return the value once it stays the same for k consecutive reads.
+// Values are compared using equal, an error resets the count.
+// Each read that is not yet stable fails with ErrNotStable, so the backoff and attempts of the options apply between reads.
+func GetStableWithOptions[T any](op func() (T, error), k int, equal func(a T, b T) bool, options Options) (T, error) {
+	var last T
+	cnt := 0
+	return GetWithOptions(func() (T, error) {
+		v, err := op()
+		if err != nil {
+			cnt = 0
+			return v, err
+		}
+		if cnt > 0 && equal(last, v) {
+			cnt++
+		} else {
+			cnt = 1
+		}
+		last = v
+		if cnt >= k {
+			return v, nil
+		}
+		return v, ErrNotStable
+	}, options)
+}
+
+// ErrResultNotAccepted is returned when the operation succeeded but its result is not accepted.
+// See GetUntil.
+var ErrResultNotAccepted = errors.New("result not accepted")
+
+// GetUntil performs the given operation until it returns a result accepted by the accept function.
+// See GetUntilWithOptions.
+func GetUntil[T any](op func() (T, error), accept func(v T) bool, retryOptions ...RetryOption) (T, error) {
+	option := NewOptions(retryOptions...)
+	return GetUntilWithOptions(op, accept, option)
+}
+
+// GetUntilWithOptions performs the given operation until it returns a result accepted by the accept function,
+// such as a non-empty list or a completed status.
+// A result that is not accepted fails the attempt with ErrResultNotAccepted, so it is retried based on the options.
+func GetUntilWithOptions[T any](op func() (T, error), accept func(v T) bool, options Options) (T, error) {
+	return GetWithOptions(func() (T, error) {
+		v, err := op()
+		if err != nil {
+			return v, err
+		}
+		if !accept(v) {
+			return v, ErrResultNotAccepted
+		}
+		return v, nil
+	}, options)
+}
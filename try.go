@@ -3,11 +3,37 @@
 import (
 	"context"
 	"errors"
+	"fmt"
+	"github.com/mawngo/go-try/backoff"
 	"time"
 )
 
 var ErrRetryAttemptsExceed = errors.New("retry attempts exceed")
 
+// ErrMaxElapsedTimeExceed is returned when the total time spent retrying exceeds the configured maximum.
+// See WithMaxElapsedTime.
+var ErrMaxElapsedTimeExceed = errors.New("max elapsed time exceed")
+
+// ErrInsufficientDeadline is returned when the context does not have enough time left for another attempt.
+// See WithMinRemainingDeadline.
+var ErrInsufficientDeadline = errors.New("insufficient deadline")
+
+// ErrMaxTotalBackoffExceed is returned when the cumulative backoff would exceed the configured maximum.
+// See WithMaxTotalBackoff.
+var ErrMaxTotalBackoffExceed = errors.New("max total backoff exceed")
+
+// ErrCircuitOpen is returned when the Breaker configured using WithBreaker does not allow an attempt.
+var ErrCircuitOpen = errors.New("circuit open")
+
+// ErrRetryStopped is returned when the backoff strategy signals to stop retrying.
+// See backoff.Stop.
+var ErrRetryStopped = errors.New("retry stopped")
+
+// ErrScheduleExhausted is returned when a schedule-based backoff strategy has no more delays.
+// It wraps ErrRetryStopped.
+// See backoff.Exhausted.
+var ErrScheduleExhausted = fmt.Errorf("schedule exhausted: %w", ErrRetryStopped)
+
 // Do perform the given operation.
 // Based on the retryOptions, it can retry the operation if it failed.
 // See RetryOption.
@@ -19,41 +45,29 @@ func Do(op func() error, retryOptions ...RetryOption) error {
 // DoWithOptions performs the given operation.
 // Based on the options, it can retry the operation if it failed.
 func DoWithOptions(op func() error, options Options) error {
-	cnt := 0
-	var lastErr error
-	ctx := options.context
-	if ctx == nil {
-		ctx = context.Background()
-	}
-
-	for {
-		if err := ctx.Err(); err != nil {
-			return combineErr(err, lastErr)
-		}
+	_, err := retry(func(_ context.Context) (struct{}, error) {
+		return struct{}{}, op()
+	}, options, false)
+	return err
+}
 
-		err := op()
-		cnt++
+// DoCtx performs the given operation, passing it the context of the retry.
+// See DoCtxWithOptions.
+func DoCtx(ctx context.Context, op func(ctx context.Context) error, retryOptions ...RetryOption) error {
+	option := NewOptions(retryOptions...)
+	return DoCtxWithOptions(ctx, op, option)
+}
 
-		if err != nil {
-			if !options.matchError(err) {
-				return combineErr(err, lastErr)
-			}
-			if options.maxAttempts > 0 && cnt >= options.maxAttempts {
-				return errors.Join(ErrRetryAttemptsExceed, combineErr(err, lastErr))
-			}
-			if options.backoffStrategy != nil {
-				time.Sleep(options.backoffStrategy(err, cnt))
-			}
-			if options.onRetry != nil {
-				options.onRetry(ctx, err, cnt)
-			}
-			if !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
-				lastErr = err
-			}
-			continue
-		}
-		return nil
-	}
+// DoCtxWithOptions performs the given operation, passing it the context of the retry,
+// so the operation can stop when the context is canceled.
+// The given ctx replaces the context configured by WithContext.
+// Based on the options, it can retry the operation if it failed.
+func DoCtxWithOptions(ctx context.Context, op func(ctx context.Context) error, options Options) error {
+	options.context = ctx
+	_, err := retry(func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, op(ctx)
+	}, options, true)
+	return err
 }
 
 // Get performs the given operation, and return the result.
@@ -66,40 +80,358 @@ func Get[T any](op func() (T, error), retryOptions ...RetryOption) (T, error) {
 // GetWithOptions performs the given operation, and return the result.
 // See DoWithOptions.
 func GetWithOptions[T any](op func() (T, error), options Options) (T, error) {
+	return retry(func(_ context.Context) (T, error) {
+		return op()
+	}, options, false)
+}
+
+// GetCtx performs the given operation, passing it the context of the retry, and return the result.
+// See GetCtxWithOptions.
+func GetCtx[T any](ctx context.Context, op func(ctx context.Context) (T, error), retryOptions ...RetryOption) (T, error) {
+	option := NewOptions(retryOptions...)
+	return GetCtxWithOptions(ctx, op, option)
+}
+
+// GetCtxWithOptions performs the given operation, passing it the context of the retry, and return the result.
+// See DoCtxWithOptions.
+func GetCtxWithOptions[T any](ctx context.Context, op func(ctx context.Context) (T, error), options Options) (T, error) {
+	options.context = ctx
+	return retry(op, options, true)
+}
+
+// GetOrElse performs the given operation, and return the result, or the fallback value if it still failed.
+// See GetOrElseWithOptions.
+func GetOrElse[T any](op func() (T, error), fallback T, retryOptions ...RetryOption) T {
+	option := NewOptions(retryOptions...)
+	return GetOrElseWithOptions(op, fallback, option)
+}
+
+// GetOrElseWithOptions performs the given operation, and return the result, or the fallback value if it still failed.
+// The error is discarded, but the give-up handlers are still called.
+// See GetWithOptions.
+func GetOrElseWithOptions[T any](op func() (T, error), fallback T, options Options) T {
+	v, err := GetWithOptions(op, options)
+	if err != nil {
+		return fallback
+	}
+	return v
+}
+
+// GetOrElseCtx performs the given operation, passing it the context of the retry,
+// and return the result, or the fallback value if it still failed.
+// See GetOrElseCtxWithOptions.
+func GetOrElseCtx[T any](ctx context.Context, op func(ctx context.Context) (T, error), fallback T, retryOptions ...RetryOption) T {
+	option := NewOptions(retryOptions...)
+	return GetOrElseCtxWithOptions(ctx, op, fallback, option)
+}
+
+// GetOrElseCtxWithOptions performs the given operation, passing it the context of the retry,
+// and return the result, or the fallback value if it still failed.
+// The error is discarded, but the give-up handlers are still called.
+// See GetCtxWithOptions.
+func GetOrElseCtxWithOptions[T any](ctx context.Context, op func(ctx context.Context) (T, error), fallback T, options Options) T {
+	v, err := GetCtxWithOptions(ctx, op, options)
+	if err != nil {
+		return fallback
+	}
+	return v
+}
+
+// retry is the retry loop shared by all entry points.
+// The attempt context is only created when the operation uses it or when handlers may receive it,
+// so the success path of an operation that ignores the context does not allocate.
+func retry[T any](op func(ctx context.Context) (T, error), options Options, usesCtx bool) (T, error) {
 	cnt := 0
-	var lastErr error
+	var prevErr error
 	ctx := options.context
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	options = applyContextOptions(ctx, options)
+	if options.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.timeout)
+		defer cancel()
+	}
+	clock := options.getClock()
+	start := clock.Now()
+	state := retryState{start: start}
+	state.budget, _ = ctx.Value(budgetKey{}).(*retryBudget)
+	hooks := hookRunner{size: options.asyncQueueSize}
+	defer hooks.close()
+	var errs []error
+	collectErrors, onGiveUp := options.collectErrors, options.onGiveUp
+	giveUp := func(ctx context.Context, err error) error {
+		if collectErrors {
+			err = &AttemptErrors{Errs: errs, Err: err}
+		}
+		if onGiveUp != nil {
+			attempts, elapsed := cnt, clock.Now().Sub(start)
+			hooks.runFinal(func() {
+				onGiveUp(ctx, err, attempts, elapsed)
+			})
+		}
+		return err
+	}
+	// The call site is captured here, as handlers may run on another goroutine, see WithAsyncHandlers.
+	var callerSite string
+	if options.captureCaller {
+		callerSite = caller()
+	}
+	withAttemptCtx := usesCtx || options.onRetry != nil || options.onRetryInfo != nil || onGiveUp != nil || options.onSuccess != nil || options.contextDecorator != nil
+
 	for {
 		if err := ctx.Err(); err != nil {
 			var empty T
-			return empty, combineErr(err, lastErr)
+			return empty, giveUp(ctx, combineErr(err, state.lastErr))
+		}
+
+		if options.limiter != nil {
+			if err := options.limiter.Wait(ctx); err != nil {
+				var empty T
+				return empty, giveUp(ctx, combineErr(err, state.lastErr))
+			}
 		}
 
-		v, err := op()
+		var v T
+		var err error
+		actx := ctx
+		if withAttemptCtx {
+			actx = context.WithValue(ctx, attemptKey{}, attemptValue{attempt: cnt + 1, lastErr: prevErr, caller: callerSite})
+			if options.contextDecorator != nil {
+				actx = options.contextDecorator(actx, cnt+1)
+			}
+		}
+		if options.bulkhead != nil {
+			if err := options.bulkhead.acquire(ctx); err != nil {
+				var empty T
+				if prevErr != nil && errors.Is(err, ErrBulkheadFull) {
+					return empty, giveUp(ctx, newRetryError(ErrBulkheadFull, prevErr, cnt, clock.Now().Sub(start)))
+				}
+				return empty, giveUp(ctx, combineErr(err, state.lastErr))
+			}
+		}
+		// The breaker is consulted last, so an allowed attempt always runs and reports its outcome.
+		if options.breaker != nil && !options.breaker.Allow() {
+			if options.bulkhead != nil {
+				options.bulkhead.release()
+			}
+			var empty T
+			if prevErr == nil {
+				return empty, giveUp(ctx, ErrCircuitOpen)
+			}
+			return empty, giveUp(ctx, newRetryError(ErrCircuitOpen, prevErr, cnt, clock.Now().Sub(start)))
+		}
+		opCtx := actx
+		var cancelAttempt context.CancelFunc
+		if timeout := options.attemptTimeout(cnt + 1); timeout > 0 {
+			opCtx, cancelAttempt = context.WithTimeout(actx, timeout)
+		}
+		attemptStart := clock.Now()
+		v, err = callAttempt(opCtx, op, options.recoverPanic, options.bulkhead, options.breaker)
+		if cancelAttempt != nil {
+			err = attemptTimedOut(ctx, opCtx, err)
+			cancelAttempt()
+		}
 		cnt++
+		prevErr = err
+		if options.report != nil {
+			options.report.Attempts = append(options.report.Attempts, AttemptReport{
+				Err:      err,
+				Start:    attemptStart,
+				Duration: clock.Now().Sub(attemptStart),
+			})
+		}
 
 		if err != nil {
-			if !options.matchError(err) {
-				return v, combineErr(err, lastErr)
+			if options.collectErrors {
+				errs = append(errs, err)
+			}
+			d, giveUpErr := options.next(ctx, clock, &state, err, cnt)
+			if giveUpErr != nil {
+				return v, giveUp(actx, giveUpErr)
+			}
+			if options.report != nil {
+				options.report.Attempts[len(options.report.Attempts)-1].Backoff = d
+				options.report.TotalBackoff += d
+			}
+			if onRetryInfo := options.onRetryInfo; onRetryInfo != nil {
+				info := RetryInfo{Retry: cnt, Err: err, Backoff: d, Elapsed: clock.Now().Sub(start)}
+				hooks.run(func() {
+					onRetryInfo(actx, info)
+				})
 			}
-			if options.maxAttempts > 0 && cnt >= options.maxAttempts {
-				return v, errors.Join(ErrRetryAttemptsExceed, combineErr(err, lastErr))
+			if onRetry := options.onRetry; onRetry != nil && options.onRetryBeforeBackoff {
+				retry := cnt
+				hooks.run(func() {
+					onRetry(actx, err, retry)
+				})
 			}
-			if options.backoffStrategy != nil {
-				time.Sleep(options.backoffStrategy(err, cnt))
+			if ctxErr := clock.Sleep(ctx, d); ctxErr != nil {
+				var empty T
+				return empty, giveUp(actx, combineErr(ctxErr, state.lastErr))
 			}
-			if options.onRetry != nil {
-				options.onRetry(ctx, err, cnt)
+			if onRetry := options.onRetry; onRetry != nil && !options.onRetryBeforeBackoff {
+				retry := cnt
+				hooks.run(func() {
+					onRetry(actx, err, retry)
+				})
 			}
 			continue
 		}
+		options.recordSuccess()
+		if onSuccess := options.onSuccess; onSuccess != nil {
+			attempts, elapsed := cnt, clock.Now().Sub(start)
+			hooks.runFinal(func() {
+				onSuccess(actx, attempts, elapsed)
+			})
+		}
 		return v, nil
 	}
 }
 
+// attemptTimeout return the timeout of the given attempt configured by WithAttemptTimeoutSchedule, or 0 if none.
+func (o *Options) attemptTimeout(attempt int) time.Duration {
+	if len(o.attemptTimeouts) == 0 {
+		return 0
+	}
+	return o.attemptTimeouts[min(attempt, len(o.attemptTimeouts))-1]
+}
+
+// attemptTimedOut mark the error as retryable if the attempt failed because its own timeout expired,
+// while the context of the retry is not done.
+func attemptTimedOut(ctx context.Context, attemptCtx context.Context, err error) error {
+	if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) && errors.Is(err, context.DeadlineExceeded) {
+		return ForceRetryable(err)
+	}
+	return err
+}
+
+// retryState is the state of a retry loop used to decide whether to retry, see Options.next.
+type retryState struct {
+	start        time.Time
+	totalBackoff time.Duration
+	lastErr      error
+	budget       *retryBudget
+}
+
+// next record the failed attempt, and return the backoff before the next attempt, or the error to give up with.
+// It is shared by the retry loop and the Scheduler, so both make the same decision.
+func (o *Options) next(ctx context.Context, clock Clock, s *retryState, err error, attempts int) (time.Duration, error) {
+	if o.dynamic != nil {
+		o.loadPolicy(o.dynamic.options.Load())
+	}
+	if !o.matchError(err) {
+		if o.breaker != nil {
+			// A timeout still indicates that the dependency is slow or down, while a canceled attempt has no outcome.
+			switch {
+			case errors.Is(err, context.DeadlineExceeded):
+				o.breaker.RecordFailure()
+			case errors.Is(err, context.Canceled):
+				o.breaker.Release()
+			default:
+				o.breaker.RecordSuccess()
+			}
+		}
+		return 0, combineErr(err, s.lastErr)
+	}
+	if o.breaker != nil {
+		o.breaker.RecordFailure()
+	}
+	if o.budget != nil {
+		o.budget.RecordFailure()
+	}
+	if o.maxAttempts > 0 && attempts >= o.maxAttempts {
+		return 0, newRetryError(ErrRetryAttemptsExceed, combineErr(err, s.lastErr), attempts, clock.Now().Sub(s.start))
+	}
+	if o.maxElapsedTime > 0 && clock.Now().Sub(s.start) >= o.maxElapsedTime {
+		return 0, newRetryError(ErrMaxElapsedTimeExceed, combineErr(err, s.lastErr), attempts, clock.Now().Sub(s.start))
+	}
+	if o.budget != nil && !o.budget.AllowRetry() {
+		return 0, newRetryError(ErrRetryBudgetExceed, combineErr(err, s.lastErr), attempts, clock.Now().Sub(s.start))
+	}
+	if s.budget != nil && !s.budget.take() {
+		return 0, newRetryError(ErrRetryBudgetExceed, combineErr(err, s.lastErr), attempts, clock.Now().Sub(s.start))
+	}
+	if !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
+		s.lastErr = err
+	}
+	var d time.Duration
+	if o.backoffStrategy != nil {
+		if o.deadlineBackoff != nil {
+			var remaining time.Duration
+			if deadline, ok := ctx.Deadline(); ok {
+				remaining = deadline.Sub(clock.Now())
+			}
+			if o.maxElapsedTime > 0 {
+				if left := o.maxElapsedTime - clock.Now().Sub(s.start); remaining == 0 || left < remaining {
+					remaining = left
+				}
+			}
+			attemptsLeft := 0
+			if o.maxAttempts > 0 {
+				attemptsLeft = o.maxAttempts - attempts
+			}
+			d = o.deadlineBackoff(err, attempts, remaining, attemptsLeft)
+		} else {
+			d = o.backoffStrategy(err, attempts)
+		}
+		if d == backoff.Exhausted {
+			return 0, newRetryError(ErrScheduleExhausted, combineErr(err, s.lastErr), attempts, clock.Now().Sub(s.start))
+		}
+		if d < 0 {
+			return 0, newRetryError(ErrRetryStopped, combineErr(err, s.lastErr), attempts, clock.Now().Sub(s.start))
+		}
+		if o.maxElapsedTime > 0 && clock.Now().Sub(s.start)+d >= o.maxElapsedTime {
+			return 0, newRetryError(ErrMaxElapsedTimeExceed, combineErr(err, s.lastErr), attempts, clock.Now().Sub(s.start))
+		}
+		if o.maxTotalBackoff > 0 && s.totalBackoff+d > o.maxTotalBackoff {
+			return 0, newRetryError(ErrMaxTotalBackoffExceed, combineErr(err, s.lastErr), attempts, clock.Now().Sub(s.start))
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok && o.minRemainingDeadline > 0 && deadline.Sub(clock.Now())-d < o.minRemainingDeadline {
+		return 0, newRetryError(ErrInsufficientDeadline, combineErr(err, s.lastErr), attempts, clock.Now().Sub(s.start))
+	}
+	s.totalBackoff += d
+	return d, nil
+}
+
+// recordSuccess record a successful attempt.
+func (o *Options) recordSuccess() {
+	if o.backoffReset != nil {
+		o.backoffReset()
+	}
+	if o.budget != nil {
+		o.budget.RecordSuccess()
+	}
+	if o.breaker != nil {
+		o.breaker.RecordSuccess()
+	}
+}
+
+// callAttempt call the operation, releasing the bulkhead slot once it returns, even if it panics.
+// The breaker attempt is released if the operation panics, as its outcome is never recorded.
+func callAttempt[T any](ctx context.Context, op func(ctx context.Context) (T, error), recoverPanic bool, b *bulkhead, br Breaker) (v T, err error) {
+	if b != nil {
+		defer b.release()
+	}
+	if recoverPanic {
+		return callRecover(ctx, op)
+	}
+	if br != nil {
+		returned := false
+		defer func() {
+			if !returned {
+				br.Release()
+			}
+		}()
+		v, err = op(ctx)
+		returned = true
+		return v, err
+	}
+	return op(ctx)
+}
+
 func combineErr(err error, last error) error {
 	if last == nil {
 		return err
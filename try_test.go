@@ -1,10 +1,16 @@
 package try
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"flag"
 	"github.com/mawngo/go-try/backoff"
 	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -183,3 +189,1849 @@ func TestWithOptions(t *testing.T) {
 	assert.Equal(t, 2, i)
 	assert.Equal(t, 1, global.maxAttempts)
 }
+
+func TestOptionsLogValue(t *testing.T) {
+	buf := bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	logger.Info("using retry policy", slog.Any("policy", NewOptions(WithAttempts(3), WithNoBackoff(),
+		WithMaxElapsedTime(time.Minute), WithTimeout(30*time.Second), WithMaxTotalBackoff(10*time.Second))))
+	assert.Contains(t, buf.String(), "policy.attempts=3")
+	assert.Contains(t, buf.String(), "policy.backoff=false")
+	assert.Contains(t, buf.String(), "policy.retryOnContextError=false")
+	assert.Contains(t, buf.String(), "policy.maxElapsedTime=1m0s")
+	assert.Contains(t, buf.String(), "policy.timeout=30s")
+	assert.Contains(t, buf.String(), "policy.maxTotalBackoff=10s")
+	assert.Contains(t, buf.String(), "policy.breaker=false")
+}
+
+func TestGetQuorum(t *testing.T) {
+	i := atomic.Int32{}
+	stale := func() (int, error) {
+		if i.Add(1) > 2 {
+			return 2, nil
+		}
+		return 1, nil
+	}
+	fresh := func() (int, error) {
+		return 2, nil
+	}
+	broken := func() (int, error) {
+		return 0, errFailed
+	}
+	num, err := GetQuorum([]func() (int, error){stale, fresh, broken}, 2, func(a int, b int) bool {
+		return a == b
+	}, WithNoBackoff())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, num)
+	assert.Equal(t, int32(3), i.Load())
+
+	_, err = GetQuorum([]func() (int, error){fresh, broken}, 2, func(a int, b int) bool {
+		return a == b
+	}, WithNoBackoff(), WithAttempts(2))
+	assert.True(t, errors.Is(err, ErrNoQuorum))
+	assert.True(t, errors.Is(err, errFailed))
+
+	for _, n := range []int{0, -1, 3} {
+		calls := atomic.Int32{}
+		_, err = GetQuorum([]func() (int, error){fresh, func() (int, error) {
+			calls.Add(1)
+			return 2, nil
+		}}, n, func(a int, b int) bool {
+			return a == b
+		}, WithNoBackoff(), WithAttempts(5))
+		assert.ErrorIs(t, err, ErrInvalidQuorum)
+		assert.Equal(t, int32(0), calls.Load())
+	}
+}
+
+func TestGetStable(t *testing.T) {
+	values := []int{1, 2, 2, 3, 3, 3, 4}
+	i := 0
+	num, err := GetStable(func() (int, error) {
+		v := values[i]
+		i++
+		return v, nil
+	}, 3, func(a int, b int) bool {
+		return a == b
+	}, WithNoBackoff(), WithAttempts(10))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, num)
+	assert.Equal(t, 6, i)
+
+	i = 0
+	_, err = GetStable(func() (int, error) {
+		i++
+		return i, nil
+	}, 2, func(a int, b int) bool {
+		return a == b
+	}, WithNoBackoff(), WithAttempts(3))
+	assert.True(t, errors.Is(err, ErrNotStable))
+	assert.Equal(t, 3, i)
+}
+
+func TestInitOnce(t *testing.T) {
+	i := 0
+	get := InitOnce(func() (int, error) {
+		i++
+		if i < 4 {
+			return 0, errFailed
+		}
+		return i, nil
+	}, WithNoBackoff(), WithAttempts(2))
+
+	_, err := get()
+	assert.True(t, errors.Is(err, errFailed))
+	assert.Equal(t, 2, i)
+
+	num, err := get()
+	assert.Nil(t, err)
+	assert.Equal(t, 4, num)
+
+	num, err = get()
+	assert.Nil(t, err)
+	assert.Equal(t, 4, num)
+	assert.Equal(t, 4, i)
+}
+
+func TestPool(t *testing.T) {
+	cnt := atomic.Int32{}
+	failures := atomic.Int32{}
+	pool := NewPool(3, func(err error) {
+		assert.True(t, errors.Is(err, errFailed))
+		failures.Add(1)
+	}, WithNoBackoff(), WithAttempts(2))
+
+	for i := 0; i < 10; i++ {
+		pool.Submit(func() error {
+			cnt.Add(1)
+			if i%2 == 0 {
+				return errFailed
+			}
+			return nil
+		})
+	}
+	pool.Close()
+	assert.Equal(t, int32(15), cnt.Load())
+	assert.Equal(t, int32(5), failures.Load())
+}
+
+func TestPoolInvalidWorkers(t *testing.T) {
+	for _, workers := range []int{0, -1} {
+		cnt := atomic.Int32{}
+		pool := NewPool(workers, nil, WithNoBackoff())
+		pool.Submit(func() error {
+			cnt.Add(1)
+			return nil
+		})
+		pool.Close()
+		assert.Equal(t, int32(1), cnt.Load())
+	}
+}
+
+func TestDoResumable(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5, 6}
+	committed := -1
+	processed := make([]int, 0, len(items))
+	failed := false
+	err := DoResumable(0, func(from int, checkpoint func(int) error) error {
+		for i := from; i < len(items); i++ {
+			if i == 3 && !failed {
+				failed = true
+				return errFailed
+			}
+			processed = append(processed, items[i])
+			if err := checkpoint(i + 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func(c int) error {
+		committed = c
+		return nil
+	}, WithNoBackoff())
+	assert.Nil(t, err)
+	assert.Equal(t, items, processed)
+	assert.Equal(t, len(items), committed)
+}
+
+func TestDoRetryStoppedByBackoff(t *testing.T) {
+	i := 0
+	err := Do(func() error {
+		i++
+		return errFailed
+	}, WithAttempts(10), WithBackoff(func(_ error, i int) time.Duration {
+		if i >= 3 {
+			return backoff.Stop
+		}
+		return 0
+	}))
+	assert.True(t, errors.Is(err, ErrRetryStopped))
+	assert.True(t, errors.Is(err, errFailed))
+	assert.Equal(t, 3, i)
+}
+
+type chanWriter chan string
+
+func (w chanWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+func TestOnRetryCallerLogging(t *testing.T) {
+	buf := bytes.Buffer{}
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer slog.SetDefault(defaultLogger)
+
+	_ = Do(func() error {
+		return errFailed
+	}, WithAttempts(2), WithNoBackoff(), WithOnRetryCallerLogging(slog.LevelInfo, "retrying"))
+	assert.Contains(t, buf.String(), "msg=retrying")
+	assert.Contains(t, buf.String(), "caller=")
+	assert.Contains(t, buf.String(), "try_test.go:")
+
+	// The call site is captured before the handlers are dispatched to the async worker.
+	logged := make(chanWriter, 1)
+	slog.SetDefault(slog.New(slog.NewTextHandler(logged, nil)))
+	_ = Do(func() error {
+		return errFailed
+	}, WithAttempts(2), WithNoBackoff(), WithAsyncHandlers(1), WithOnRetryCallerLogging(slog.LevelInfo, "retrying"))
+	assert.Contains(t, <-logged, "try_test.go:")
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	// Reaching DefaultMaxAttempts does not escalate the level of later retries.
+	buf.Reset()
+	handler := NewOnRetryCallerLoggingHandler(slog.LevelInfo, "retrying")
+	handler(context.Background(), errFailed, DefaultMaxAttempts)
+	handler(context.Background(), errFailed, 1)
+	assert.Equal(t, 1, strings.Count(buf.String(), "level=ERROR"))
+	assert.Equal(t, 1, strings.Count(buf.String(), "level=INFO"))
+}
+
+type registeredErr struct{}
+
+func (registeredErr) Error() string {
+	return "registered"
+}
+
+func TestRegisterRetryability(t *testing.T) {
+	errRegistered := errors.New("registered non retryable")
+	RegisterNonRetryable(errRegistered)
+	RegisterRetryable[registeredErr]()
+
+	i := 0
+	err := Do(func() error {
+		i++
+		return errRegistered
+	}, WithNoBackoff())
+	assert.True(t, errors.Is(err, errRegistered))
+	assert.Equal(t, 1, i)
+
+	i = 0
+	err = Do(func() error {
+		i++
+		return errors.Join(context.Canceled, registeredErr{})
+	}, WithNoBackoff())
+	assert.True(t, errors.Is(err, ErrRetryAttemptsExceed))
+	assert.Equal(t, DefaultMaxAttempts, i)
+
+	i = 0
+	err = Do(func() error {
+		i++
+		return errRegistered
+	}, WithNoBackoff(), WithRetryFor(errRegistered))
+	assert.True(t, errors.Is(err, ErrRetryAttemptsExceed))
+	assert.Equal(t, DefaultMaxAttempts, i)
+}
+
+func TestAll(t *testing.T) {
+	running := atomic.Int32{}
+	maxRunning := atomic.Int32{}
+	cnt := atomic.Int32{}
+	ops := make([]func(ctx context.Context) error, 6)
+	for i := range ops {
+		ops[i] = func(_ context.Context) error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			cnt.Add(1)
+			time.Sleep(10 * time.Millisecond)
+			if i == 4 {
+				return errFailed
+			}
+			return nil
+		}
+	}
+	err := All(context.Background(), ops, WithParallelism(2), WithAttempts(2), WithNoBackoff())
+	assert.True(t, errors.Is(err, errFailed))
+	assert.Contains(t, err.Error(), "operation 4")
+	assert.Equal(t, int32(7), cnt.Load())
+	assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+}
+
+func TestDoRetryScheduleExhausted(t *testing.T) {
+	i := 0
+	err := Do(func() error {
+		i++
+		return errFailed
+	}, WithAttempts(0), WithBackoff(backoff.NewFiniteScheduleBackoff(time.Millisecond, 2*time.Millisecond)))
+	assert.True(t, errors.Is(err, ErrScheduleExhausted))
+	assert.True(t, errors.Is(err, ErrRetryStopped))
+	assert.True(t, errors.Is(err, errFailed))
+	assert.Equal(t, 3, i)
+}
+
+func TestDoForceRetryable(t *testing.T) {
+	i := 0
+	err := Do(func() error {
+		i++
+		if i >= 3 {
+			return errFailed
+		}
+		return ForceRetryable(errFailed)
+	}, WithNoRetryFor(errFailed), WithNoBackoff())
+	assert.True(t, errors.Is(err, errFailed))
+	assert.False(t, IsForceRetryable(err))
+	assert.True(t, IsForceRetryable(ForceRetryable(errFailed)))
+	assert.Equal(t, 3, i)
+}
+
+func TestGiveUpLogging(t *testing.T) {
+	buf := bytes.Buffer{}
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	defer slog.SetDefault(defaultLogger)
+
+	_ = Do(func() error {
+		return nil
+	}, WithGiveUpLogging(slog.LevelError, "gave up"))
+	assert.Empty(t, buf.String())
+
+	_ = Do(func() error {
+		return errFailed
+	}, WithAttempts(3), WithNoBackoff(), WithGiveUpLogging(slog.LevelError, "gave up"))
+	assert.Equal(t, 1, strings.Count(buf.String(), "msg=\"gave up\""))
+	assert.Contains(t, buf.String(), "attempts=3")
+	assert.Contains(t, buf.String(), "elapsed=")
+}
+
+func TestBindFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	option := BindFlags(fs, "db-")
+	err := fs.Parse([]string{"-db-retry-attempts=3", "-db-retry-backoff=10ms", "-db-retry-max-backoff=15ms"})
+	assert.Nil(t, err)
+
+	opt := NewOptions(option)
+	assert.Equal(t, 3, opt.maxAttempts)
+	assert.Equal(t, 10*time.Millisecond, opt.backoffStrategy(errFailed, 1))
+	assert.Equal(t, 15*time.Millisecond, opt.backoffStrategy(errFailed, 2))
+
+	err = fs.Parse([]string{"-db-retry-backoff=0"})
+	assert.Nil(t, err)
+	assert.Nil(t, NewOptions(option).backoffStrategy)
+}
+
+func TestOptionsFromEnv(t *testing.T) {
+	options, err := OptionsFromEnv("TEST_TRY_")
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultMaxAttempts, options.maxAttempts)
+	assert.Equal(t, DefaultBackoff, options.backoffStrategy(errFailed, 3))
+
+	t.Setenv("TEST_TRY_ATTEMPTS", "3")
+	t.Setenv("TEST_TRY_BACKOFF", "10ms")
+	t.Setenv("TEST_TRY_MAX_BACKOFF", "15ms")
+	t.Setenv("TEST_TRY_MAX_ELAPSED", "1m")
+	options, err = OptionsFromEnv("TEST_TRY_")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, options.maxAttempts)
+	assert.Equal(t, time.Minute, options.maxElapsedTime)
+	assert.Equal(t, 10*time.Millisecond, options.backoffStrategy(errFailed, 1))
+	assert.Equal(t, 15*time.Millisecond, options.backoffStrategy(errFailed, 2))
+
+	t.Setenv("TEST_TRY_BACKOFF", "0")
+	options, err = OptionsFromEnv("TEST_TRY_")
+	assert.Nil(t, err)
+	assert.Nil(t, options.backoffStrategy)
+
+	t.Setenv("TEST_TRY_ATTEMPTS", "-1")
+	_, err = OptionsFromEnv("TEST_TRY_")
+	assert.ErrorIs(t, err, ErrInvalidOptions)
+
+	// Unlimited attempts without backoff would busy-loop.
+	t.Setenv("TEST_TRY_ATTEMPTS", "0")
+	t.Setenv("TEST_TRY_MAX_ELAPSED", "")
+	_, err = OptionsFromEnv("TEST_TRY_")
+	assert.ErrorIs(t, err, ErrInvalidOptions)
+
+	t.Setenv("TEST_TRY_ATTEMPTS", "many")
+	_, err = OptionsFromEnv("TEST_TRY_")
+	assert.ErrorContains(t, err, "TEST_TRY_ATTEMPTS")
+}
+
+func TestGetAll(t *testing.T) {
+	cnt := atomic.Int32{}
+	values, errs := GetAll(context.Background(), []string{"a", "b", "c"}, func(_ context.Context, key string) (int, error) {
+		if cnt.Add(1) == 1 || key == "c" {
+			return 0, errFailed
+		}
+		return len(key), nil
+	}, WithNoBackoff(), WithAttempts(2), WithParallelism(1))
+	assert.Equal(t, map[string]int{"a": 1, "b": 1}, values)
+	assert.Len(t, errs, 1)
+	assert.True(t, errors.Is(errs["c"], errFailed))
+	assert.Equal(t, int32(5), cnt.Load())
+}
+
+func TestAsyncHandlers(t *testing.T) {
+	release := make(chan struct{})
+	done := make(chan struct{})
+	handled := atomic.Int32{}
+	i := 0
+	err := Do(func() error {
+		i++
+		return errFailed
+	}, WithAttempts(5), WithNoBackoff(), WithAsyncHandlers(2), WithOnRetry(func(_ context.Context, _ error, _ int) {
+		<-release
+		if handled.Add(1) == 2 {
+			close(done)
+		}
+	}))
+	assert.True(t, errors.Is(err, errFailed))
+	assert.Equal(t, 5, i)
+
+	close(release)
+	<-done
+	assert.LessOrEqual(t, handled.Load(), int32(3))
+}
+
+func TestAsyncHandlersGiveUpNotDropped(t *testing.T) {
+	release := make(chan struct{})
+	gaveUp := make(chan error, 1)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+	}()
+	err := Do(func() error {
+		return errFailed
+	}, WithAttempts(5), WithNoBackoff(), WithAsyncHandlers(1), WithOnRetry(func(_ context.Context, _ error, _ int) {
+		<-release
+	}), WithOnGiveUp(func(_ context.Context, err error, _ int) {
+		gaveUp <- err
+	}))
+	assert.ErrorIs(t, err, errFailed)
+	select {
+	case err := <-gaveUp:
+		assert.ErrorIs(t, err, ErrRetryAttemptsExceed)
+	case <-time.After(time.Second):
+		t.Fatal("give-up handler dropped")
+	}
+}
+
+func TestContextWithBudget(t *testing.T) {
+	ctx := ContextWithBudget(context.Background(), 4)
+	outer := 0
+	inner := 0
+	err := Do(func() error {
+		outer++
+		return Do(func() error {
+			inner++
+			return errFailed
+		}, WithContext(ctx), WithNoBackoff(), WithAttempts(3))
+	}, WithContext(ctx), WithNoBackoff(), WithAttempts(3))
+	assert.True(t, errors.Is(err, ErrRetryBudgetExceed))
+	assert.True(t, errors.Is(err, errFailed))
+	assert.Equal(t, 2, outer)
+	assert.Equal(t, 5, inner)
+
+	remaining, ok := RemainingBudget(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, 0, remaining)
+
+	nested := ContextWithBudget(ContextWithBudget(context.Background(), 1), 5)
+	remaining, _ = RemainingBudget(nested)
+	assert.Equal(t, 1, remaining)
+}
+
+func TestBackoffTuningOptions(t *testing.T) {
+	opt := NewOptions(WithFixedBackoff(100*time.Millisecond), WithMultiplier(1.5), WithMaxBackoff(300*time.Millisecond))
+	assert.Equal(t, 100*time.Millisecond, opt.backoffStrategy(errFailed, 1))
+	assert.Equal(t, 150*time.Millisecond, opt.backoffStrategy(errFailed, 2))
+	assert.Equal(t, 225*time.Millisecond, opt.backoffStrategy(errFailed, 3))
+	assert.Equal(t, 300*time.Millisecond, opt.backoffStrategy(errFailed, 4))
+
+	opt = NewOptions(WithFixedBackoff(100*time.Millisecond), WithJitter(50*time.Millisecond))
+	d := opt.backoffStrategy(errFailed, 1)
+	assert.GreaterOrEqual(t, d, 100*time.Millisecond)
+	assert.Less(t, d, 150*time.Millisecond)
+
+	opt = NewOptions(WithNoBackoff(), WithMultiplier(2), WithJitter(time.Second), WithMaxBackoff(time.Second))
+	assert.Nil(t, opt.backoffStrategy)
+
+	// The multiplied backoff does not overflow into a negative duration.
+	opt = NewOptions(WithFixedBackoff(time.Second), WithMultiplier(2), WithMaxBackoff(time.Hour))
+	assert.Equal(t, time.Hour, opt.backoffStrategy(errFailed, 35))
+	assert.Equal(t, time.Hour, opt.backoffStrategy(errFailed, 100))
+
+	// A max backoff of 0 means no maximum.
+	opt = NewOptions(WithFixedBackoff(time.Second), WithMaxBackoff(0))
+	assert.NoError(t, opt.Validate())
+	assert.Equal(t, time.Second, opt.backoffStrategy(errFailed, 3))
+
+	// An invalid multiplier is reported, and does not turn the backoff into Stop.
+	opt = NewOptions(WithFixedBackoff(time.Millisecond), WithMultiplier(-2))
+	assert.ErrorIs(t, opt.Validate(), ErrInvalidOptions)
+	assert.Equal(t, time.Millisecond, opt.backoffStrategy(errFailed, 2))
+}
+
+func TestGetOrElse(t *testing.T) {
+	gaveUp := 0
+	num := GetOrElse(func() (int, error) {
+		return 1, errFailed
+	}, 10, WithNoBackoff(), WithAttempts(2), WithOnGiveUp(func(_ context.Context, _ error, _ int) {
+		gaveUp++
+	}))
+	assert.Equal(t, 10, num)
+	assert.Equal(t, 1, gaveUp)
+
+	num = GetOrElse(func() (int, error) {
+		return 1, nil
+	}, 10)
+	assert.Equal(t, 1, num)
+}
+
+func TestContextDecorator(t *testing.T) {
+	type keyType struct{}
+	var keys []string
+	err := DoCtx(context.Background(), func(ctx context.Context) error {
+		key, _ := ctx.Value(keyType{}).(string)
+		keys = append(keys, key)
+		if attempt, _ := AttemptFromContext(ctx); attempt < 3 {
+			return errFailed
+		}
+		return nil
+	}, WithNoBackoff(), WithContextDecorator(func(ctx context.Context, attempt int) context.Context {
+		return context.WithValue(ctx, keyType{}, "key-"+strconv.Itoa(attempt))
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"key-1", "key-2", "key-3"}, keys)
+}
+
+func TestAttemptTimeoutSchedule(t *testing.T) {
+	var deadlines []time.Duration
+	err := DoCtx(context.Background(), func(ctx context.Context) error {
+		deadline, _ := ctx.Deadline()
+		deadlines = append(deadlines, time.Until(deadline))
+		if len(deadlines) < 4 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}, WithNoBackoff(), WithAttemptTimeoutSchedule(10*time.Millisecond, 20*time.Millisecond, 30*time.Millisecond))
+	assert.NoError(t, err)
+	assert.Len(t, deadlines, 4)
+	for i, expected := range []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond, 30 * time.Millisecond} {
+		assert.LessOrEqual(t, deadlines[i], expected)
+		assert.Greater(t, deadlines[i], expected-5*time.Millisecond)
+	}
+
+	// The timeout of the retry is not retried.
+	i := 0
+	err = DoCtx(context.Background(), func(ctx context.Context) error {
+		i++
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithNoBackoff(), WithUnlimitedAttempts(), WithTimeout(50*time.Millisecond), WithAttemptTimeoutSchedule(time.Minute))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, i)
+
+	assert.ErrorIs(t, NewOptions(WithAttemptTimeoutSchedule(-time.Second)).Validate(), ErrInvalidOptions)
+}
+
+func TestGetOrElseCtx(t *testing.T) {
+	gaveUp := 0
+	ctx, cancel := context.WithCancel(context.Background())
+	num := GetOrElseCtx(ctx, func(ctx context.Context) (int, error) {
+		cancel()
+		return 1, ctx.Err()
+	}, 10, WithNoBackoff(), WithOnGiveUp(func(_ context.Context, err error, _ int) {
+		assert.ErrorIs(t, err, context.Canceled)
+		gaveUp++
+	}))
+	assert.Equal(t, 10, num)
+	assert.Equal(t, 1, gaveUp)
+
+	num = GetOrElseCtx(context.Background(), func(_ context.Context) (int, error) {
+		return 1, nil
+	}, 10)
+	assert.Equal(t, 1, num)
+}
+
+func TestContextWithOptions(t *testing.T) {
+	ctx := ContextWithOptions(context.Background(), WithAttempts(2))
+	ctx = ContextWithOptions(ctx, WithNoBackoff())
+	i := 0
+	start := time.Now()
+	err := Do(func() error {
+		i++
+		return errFailed
+	}, WithContext(ctx), WithAttempts(10), WithFixedBackoff(time.Second))
+	assert.True(t, errors.Is(err, ErrRetryAttemptsExceed))
+	assert.Equal(t, 2, i)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestDoRetryBackoffInterruptedByContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	i := 0
+	start := time.Now()
+	err := Do(func() error {
+		i++
+		return errFailed
+	}, WithContext(ctx), WithFixedBackoff(time.Second))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.True(t, errors.Is(err, errFailed))
+	assert.Equal(t, 1, i)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestDoCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	i := 0
+	err := DoCtx(ctx, func(ctx context.Context) error {
+		i++
+		if i == 2 {
+			cancel()
+			return ctx.Err()
+		}
+		return errFailed
+	}, WithNoBackoff())
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, 2, i)
+
+	num, err := GetCtx(context.Background(), func(_ context.Context) (int, error) {
+		return 1, nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, num)
+}
+
+func TestDoRetryMaxElapsedTime(t *testing.T) {
+	i := 0
+	start := time.Now()
+	err := Do(func() error {
+		i++
+		return errFailed
+	}, WithUnlimitedAttempts(), WithFixedBackoff(40*time.Millisecond), WithMaxElapsedTime(100*time.Millisecond))
+	assert.True(t, errors.Is(err, ErrMaxElapsedTimeExceed))
+	assert.True(t, errors.Is(err, errFailed))
+	assert.Equal(t, 3, i)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestRetrier(t *testing.T) {
+	errAnother := errors.New("another")
+	r := New(WithAttempts(3), WithNoBackoff(), WithRetryIf(ErrIs(errFailed), ErrIs(errAnother)))
+	i := 0
+	err := r.Do(func() error {
+		i++
+		return errFailed
+	})
+	assert.True(t, errors.Is(err, ErrRetryAttemptsExceed))
+	assert.Equal(t, 3, i)
+
+	i = 0
+	num, err := GetCtxWithRetrier(context.Background(), func(_ context.Context) (int, error) {
+		i++
+		if i < 2 {
+			return 0, errAnother
+		}
+		return i, nil
+	}, r)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, num)
+	assert.Equal(t, 3, r.Options().maxAttempts)
+}
+
+func TestDoRecoverPanic(t *testing.T) {
+	i := 0
+	err := Do(func() error {
+		i++
+		if i < 3 {
+			panic("boom")
+		}
+		panic(errFailed)
+	}, WithAttempts(3), WithNoBackoff(), WithRecoverPanic())
+	var panicErr *PanicError
+	assert.True(t, errors.As(err, &panicErr))
+	assert.Equal(t, errFailed, panicErr.Value)
+	assert.NotEmpty(t, panicErr.Stack)
+	assert.True(t, errors.Is(err, errFailed))
+	assert.Equal(t, 3, i)
+}
+
+func TestDoUnrecoverable(t *testing.T) {
+	i := 0
+	err := Do(func() error {
+		i++
+		if i >= 2 {
+			return Unrecoverable(ForceRetryable(errFailed))
+		}
+		return errFailed
+	}, WithRetryFor(errFailed), WithNoBackoff())
+	assert.True(t, IsUnrecoverable(err))
+	assert.True(t, errors.Is(err, errFailed))
+	assert.False(t, IsUnrecoverable(errFailed))
+	assert.Nil(t, Unrecoverable(nil))
+	assert.Equal(t, 2, i)
+}
+
+func TestDoRetryWithOnRetryInfo(t *testing.T) {
+	infos := make([]RetryInfo, 0, 2)
+	err := Do(func() error {
+		return errFailed
+	}, WithAttempts(3), WithBackoff(backoff.NewIncrementalBackoff(10*time.Millisecond, 10*time.Millisecond, 0)),
+		WithOnRetryInfo(func(_ context.Context, info RetryInfo) {
+			infos = append(infos, info)
+		}))
+	assert.True(t, errors.Is(err, errFailed))
+	assert.Len(t, infos, 2)
+	assert.Equal(t, 1, infos[0].Retry)
+	assert.Equal(t, errFailed, infos[0].Err)
+	assert.Equal(t, 10*time.Millisecond, infos[0].Backoff)
+	assert.Equal(t, 20*time.Millisecond, infos[1].Backoff)
+	assert.GreaterOrEqual(t, infos[1].Elapsed, 10*time.Millisecond)
+}
+
+func TestDoRetryWithOnRetryBeforeBackoff(t *testing.T) {
+	start := time.Now()
+	var firstRetry time.Duration
+	err := Do(func() error {
+		return errFailed
+	}, WithAttempts(2), WithFixedBackoff(50*time.Millisecond), WithOnRetryBeforeBackoff(), WithOnRetry(func(_ context.Context, _ error, _ int) {
+		firstRetry = time.Since(start)
+	}))
+	assert.True(t, errors.Is(err, errFailed))
+	assert.Less(t, firstRetry, 50*time.Millisecond)
+	assert.Greater(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestDoRetryWithOnGiveUp(t *testing.T) {
+	calls := 0
+	attempts := 0
+	var lastErr error
+	handler := func(_ context.Context, err error, i int) {
+		calls++
+		attempts = i
+		lastErr = err
+	}
+	errAnother := errors.New("another")
+	i := 0
+	err := Do(func() error {
+		i++
+		if i >= 2 {
+			return errAnother
+		}
+		return errFailed
+	}, WithNoBackoff(), WithRetryFor(errFailed), WithOnGiveUp(handler))
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, err, lastErr)
+
+	err = Do(func() error {
+		return nil
+	}, WithOnGiveUp(handler))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestDoRetryWithOnSuccess(t *testing.T) {
+	attempts := 0
+	i := 0
+	err := Do(func() error {
+		i++
+		if i < 3 {
+			return errFailed
+		}
+		return nil
+	}, WithFixedBackoff(10*time.Millisecond), WithOnSuccess(func(_ context.Context, n int, elapsed time.Duration) {
+		attempts = n
+		assert.GreaterOrEqual(t, elapsed, 20*time.Millisecond)
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestGetReport(t *testing.T) {
+	i := 0
+	num, report, err := GetReport(func() (int, error) {
+		i++
+		if i < 3 {
+			return 0, errFailed
+		}
+		return i, nil
+	}, WithFixedBackoff(10*time.Millisecond))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, num)
+	assert.Len(t, report.Attempts, 3)
+	assert.Equal(t, errFailed, report.Attempts[0].Err)
+	assert.Equal(t, 10*time.Millisecond, report.Attempts[0].Backoff)
+	assert.Nil(t, report.Attempts[2].Err)
+	assert.Zero(t, report.Attempts[2].Backoff)
+	assert.Equal(t, 20*time.Millisecond, report.TotalBackoff)
+	assert.GreaterOrEqual(t, report.Elapsed, report.TotalBackoff)
+	assert.True(t, report.Attempts[1].Start.After(report.Attempts[0].Start))
+}
+
+func TestDoRetryCollectErrors(t *testing.T) {
+	errAnother := errors.New("another")
+	i := 0
+	err := Do(func() error {
+		i++
+		if i == 2 {
+			return errAnother
+		}
+		return errFailed
+	}, WithAttempts(3), WithNoBackoff(), WithCollectErrors())
+	var attemptErrs *AttemptErrors
+	assert.True(t, errors.As(err, &attemptErrs))
+	assert.Equal(t, []error{errFailed, errAnother, errFailed}, attemptErrs.Errs)
+	assert.True(t, errors.Is(err, ErrRetryAttemptsExceed))
+	assert.True(t, errors.Is(err, errAnother))
+	assert.Contains(t, err.Error(), "attempt 2: another")
+}
+
+func TestDoRetryError(t *testing.T) {
+	err := Do(func() error {
+		return errFailed
+	}, WithAttempts(3), WithFixedBackoff(10*time.Millisecond))
+	var retryErr *RetryError
+	assert.True(t, errors.As(err, &retryErr))
+	assert.True(t, errors.Is(err, ErrRetryAttemptsExceed))
+	assert.Equal(t, ErrRetryAttemptsExceed, retryErr.Reason())
+	assert.Equal(t, 3, retryErr.Attempts())
+	assert.Equal(t, errFailed, retryErr.LastErr())
+	assert.GreaterOrEqual(t, retryErr.Elapsed(), 20*time.Millisecond)
+	assert.Equal(t, "retry attempts exceed\nfailed", err.Error())
+}
+
+func TestDoRetryMinRemainingDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	i := 0
+	start := time.Now()
+	err := DoCtx(ctx, func(_ context.Context) error {
+		i++
+		return errFailed
+	}, WithUnlimitedAttempts(), WithFixedBackoff(50*time.Millisecond), WithMinRemainingDeadline(80*time.Millisecond))
+	assert.True(t, errors.Is(err, ErrInsufficientDeadline))
+	assert.True(t, errors.Is(err, errFailed))
+	assert.Equal(t, 3, i)
+	assert.Less(t, time.Since(start), 150*time.Millisecond)
+}
+
+func TestAttemptFromContext(t *testing.T) {
+	_, ok := AttemptFromContext(context.Background())
+	assert.False(t, ok)
+
+	attempts := make([]int, 0, 3)
+	lastErrs := make([]error, 0, 3)
+	retries := make([]int, 0, 2)
+	err := DoCtx(context.Background(), func(ctx context.Context) error {
+		attempt, _ := AttemptFromContext(ctx)
+		attempts = append(attempts, attempt)
+		lastErrs = append(lastErrs, LastErrFromContext(ctx))
+		if attempt < 3 {
+			return errFailed
+		}
+		return nil
+	}, WithNoBackoff(), WithOnRetry(func(ctx context.Context, _ error, _ int) {
+		attempt, _ := AttemptFromContext(ctx)
+		retries = append(retries, attempt)
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3}, attempts)
+	assert.Equal(t, []error{nil, errFailed, errFailed}, lastErrs)
+	assert.Equal(t, []int{1, 2}, retries)
+}
+
+func TestGetUntil(t *testing.T) {
+	i := 0
+	status, err := GetUntil(func() (string, error) {
+		i++
+		if i < 3 {
+			return "pending", nil
+		}
+		return "done", nil
+	}, func(status string) bool {
+		return status == "done"
+	}, WithNoBackoff())
+	assert.Nil(t, err)
+	assert.Equal(t, "done", status)
+	assert.Equal(t, 3, i)
+
+	status, err = GetUntil(func() (string, error) {
+		return "pending", nil
+	}, func(status string) bool {
+		return status == "done"
+	}, WithNoBackoff(), WithAttempts(2))
+	assert.True(t, errors.Is(err, ErrResultNotAccepted))
+	assert.Equal(t, "pending", status)
+}
+
+func TestGet2AndGet3(t *testing.T) {
+	i := 0
+	num, str, err := Get2(func() (int, string, error) {
+		i++
+		if i < 2 {
+			return 0, "", errFailed
+		}
+		return i, "ok", nil
+	}, WithNoBackoff())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, num)
+	assert.Equal(t, "ok", str)
+
+	num, str, ok, err := Get3Ctx(context.Background(), func(_ context.Context) (int, string, bool, error) {
+		return 1, "ok", true, nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, num)
+	assert.Equal(t, "ok", str)
+	assert.True(t, ok)
+}
+
+func TestWrap(t *testing.T) {
+	i := 0
+	op := Wrap(func() error {
+		i++
+		return errFailed
+	}, WithAttempts(2), WithNoBackoff())
+	assert.True(t, errors.Is(op(), errFailed))
+	assert.True(t, errors.Is(op(), errFailed))
+	assert.Equal(t, 4, i)
+
+	i = 0
+	lookup := Wrap1(func(_ context.Context, key string) (int, error) {
+		i++
+		if i < 2 {
+			return 0, errFailed
+		}
+		return len(key), nil
+	}, WithNoBackoff())
+	num, err := lookup(context.Background(), "abc")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, num)
+
+	add := Wrap2(func(_ context.Context, a int, b int) (int, error) {
+		return a + b, nil
+	})
+	num, err = add(context.Background(), 1, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, num)
+}
+
+type countingBackoff struct {
+	failures int
+}
+
+func (b *countingBackoff) Next(_ error, _ int) time.Duration {
+	b.failures++
+	return time.Duration(b.failures) * time.Millisecond
+}
+
+func (b *countingBackoff) Reset() {
+	b.failures = 0
+}
+
+func TestRetrierStatefulBackoff(t *testing.T) {
+	strategy := &countingBackoff{}
+	r := New(WithAttempts(2), WithStatefulBackoff(strategy))
+	err := r.Do(func() error {
+		return errFailed
+	})
+	assert.True(t, errors.Is(err, errFailed))
+	_ = r.Do(func() error {
+		return errFailed
+	})
+	assert.Equal(t, 2, strategy.failures)
+
+	_ = r.Do(func() error {
+		return nil
+	})
+	assert.Equal(t, 0, strategy.failures)
+
+	var stateful backoff.StatefulStrategy = backoff.NewFixedBackoff(time.Second)
+	assert.Equal(t, time.Second, stateful.Next(nil, 1))
+}
+
+func TestDoRetrySwitchBackoff(t *testing.T) {
+	errRateLimited := errors.New("rate limited")
+	delays := make([]time.Duration, 0, 2)
+	i := 0
+	err := Do(func() error {
+		i++
+		if i == 1 {
+			return errRateLimited
+		}
+		return errFailed
+	}, WithAttempts(3), WithBackoff(backoff.Switch(backoff.NewFixedBackoff(time.Millisecond),
+		backoff.When(ErrIs(errRateLimited), backoff.NewFixedBackoff(20*time.Millisecond)),
+	)), WithOnRetryInfo(func(_ context.Context, info RetryInfo) {
+		delays = append(delays, info.Backoff)
+	}))
+	assert.True(t, errors.Is(err, errFailed))
+	assert.Equal(t, []time.Duration{20 * time.Millisecond, time.Millisecond}, delays)
+}
+
+func TestDoRetryAfterHint(t *testing.T) {
+	delays := make([]time.Duration, 0, 2)
+	i := 0
+	err := Do(func() error {
+		i++
+		if i == 1 {
+			return WithRetryAfter(errFailed, 20*time.Millisecond)
+		}
+		return errFailed
+	}, WithAttempts(3), WithBackoff(backoff.NewHintAwareBackoff(backoff.NewFixedBackoff(time.Millisecond))),
+		WithOnRetryInfo(func(_ context.Context, info RetryInfo) {
+			delays = append(delays, info.Backoff)
+		}))
+	assert.True(t, errors.Is(err, errFailed))
+	assert.Equal(t, []time.Duration{20 * time.Millisecond, time.Millisecond}, delays)
+	assert.Nil(t, WithRetryAfter(nil, time.Second))
+}
+
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Sleep(_ context.Context, d time.Duration) error {
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+	return nil
+}
+
+func TestDoRetryWithClock(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	err := Do(func() error {
+		return errFailed
+	}, WithClock(clock),
+		WithAttempts(4),
+		WithBackoff(backoff.NewExponentialBackoff(time.Hour, 2, 10*time.Hour)))
+	assert.ErrorIs(t, err, ErrRetryAttemptsExceed)
+	assert.Equal(t, []time.Duration{time.Hour, 2 * time.Hour, 4 * time.Hour}, clock.sleeps)
+
+	var retryErr *RetryError
+	assert.ErrorAs(t, err, &retryErr)
+	assert.Equal(t, 7*time.Hour, retryErr.Elapsed())
+
+	clock = &fakeClock{now: time.Unix(0, 0)}
+	err = Do(func() error {
+		return errFailed
+	}, WithClock(clock), WithUnlimitedAttempts(), WithFixedBackoff(time.Minute), WithMaxElapsedTime(time.Hour))
+	assert.ErrorIs(t, err, ErrMaxElapsedTimeExceed)
+	assert.Len(t, clock.sleeps, 59)
+}
+
+func TestDoWithRetryAfterHints(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	i := 0
+	err := Do(func() error {
+		i++
+		if i == 1 {
+			return WithRetryAfter(errFailed, time.Minute)
+		}
+		return errFailed
+	}, WithClock(clock), WithAttempts(3), WithFixedBackoff(time.Second), WithRetryAfterHints())
+	assert.ErrorIs(t, err, ErrRetryAttemptsExceed)
+	assert.Equal(t, []time.Duration{time.Minute, time.Second}, clock.sleeps)
+	// The hint is limited by the max backoff configured before it.
+	clock = &fakeClock{now: time.Unix(0, 0)}
+	err = Do(func() error {
+		return WithRetryAfter(errFailed, time.Hour)
+	}, WithClock(clock), WithAttempts(2), WithFixedBackoff(time.Second), WithMaxBackoff(10*time.Second), WithRetryAfterHints())
+	assert.ErrorIs(t, err, ErrRetryAttemptsExceed)
+	assert.Equal(t, []time.Duration{10 * time.Second}, clock.sleeps)
+}
+
+func TestDoWithOptionsZeroAlloc(t *testing.T) {
+	op := func() error { return nil }
+	options := NewOptions(WithAttempts(3))
+	assert.Zero(t, testing.AllocsPerRun(100, func() {
+		_ = DoWithOptions(op, options)
+	}))
+	assert.Zero(t, testing.AllocsPerRun(100, func() {
+		_ = Do(op)
+	}))
+}
+
+func BenchmarkDo(b *testing.B) {
+	op := func() error { return nil }
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = Do(op)
+	}
+}
+
+func BenchmarkDoWithOptions(b *testing.B) {
+	op := func() error { return nil }
+	options := NewOptions(WithAttempts(3), WithRetryIf(func(_ error) bool { return true }))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = DoWithOptions(op, options)
+	}
+}
+
+func BenchmarkRetrierDo(b *testing.B) {
+	op := func() error { return nil }
+	r := New(WithAttempts(3))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = r.Do(op)
+	}
+}
+
+func BenchmarkGetWithOptions(b *testing.B) {
+	op := func() (int, error) { return 1, nil }
+	options := NewOptions()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = GetWithOptions(op, options)
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	assert.NoError(t, NewOptions().Validate())
+	assert.NoError(t, NewOptions(WithUnlimitedAttempts(), WithExponentialBackoff(time.Second, time.Minute)).Validate())
+	// A max backoff of 0 means no maximum.
+	assert.NoError(t, NewOptions(WithExponentialBackoff(200*time.Millisecond, 0)).Validate())
+	assert.NoError(t, NewOptions(WithUnlimitedAttempts(), WithNoBackoff(), WithRetryFor(errFailed)).Validate())
+
+	err := NewOptions(WithAttempts(-1)).Validate()
+	assert.ErrorIs(t, err, ErrInvalidOptions)
+	assert.ErrorContains(t, err, "negative attempts -1")
+
+	err = NewOptions(WithUnlimitedAttempts(), WithNoBackoff()).Validate()
+	assert.ErrorContains(t, err, "busy-loop")
+
+	err = NewOptions(WithExponentialBackoff(time.Minute, time.Second), WithJitter(-time.Second)).Validate()
+	assert.ErrorIs(t, err, ErrInvalidOptions)
+	assert.ErrorContains(t, err, "max backoff 1s is smaller than initial backoff 1m0s")
+	assert.ErrorContains(t, err, "negative jitter -1s")
+
+	// Problems recorded by an option do not leak into the options it was derived from.
+	base := NewOptions(WithFixedBackoff(-time.Second))
+	derived := NewOptions(WithOptions(base), WithMultiplier(0))
+	assert.ErrorContains(t, derived.Validate(), "non-positive multiplier 0")
+	assert.NotContains(t, base.Validate().Error(), "multiplier")
+}
+
+func TestOptionsWith(t *testing.T) {
+	base := NewOptions(WithAttempts(3), WithRetryFor(errFailed))
+	assert.Equal(t, 3, base.Attempts())
+	assert.True(t, base.HasBackoff())
+	assert.True(t, base.HasRetryIf())
+	assert.False(t, base.HasNoRetryIf())
+	assert.False(t, base.RetryOnContextError())
+	assert.Nil(t, base.Context())
+
+	derived := base.With(WithUnlimitedAttempts(), WithNoBackoff(), WithMaxElapsedTime(time.Second), WithRetryOnContextError())
+	assert.Equal(t, 0, derived.Attempts())
+	assert.False(t, derived.HasBackoff())
+	assert.Equal(t, time.Second, derived.MaxElapsedTime())
+	assert.True(t, derived.RetryOnContextError())
+
+	assert.Equal(t, 3, base.Attempts())
+	assert.True(t, base.HasBackoff())
+	assert.Equal(t, time.Duration(0), base.MaxElapsedTime())
+	assert.False(t, base.RetryOnContextError())
+
+	clone := base.Clone()
+	assert.Equal(t, base.Attempts(), clone.Attempts())
+	assert.Equal(t, base.HasRetryIf(), clone.HasRetryIf())
+}
+
+func TestParsePolicy(t *testing.T) {
+	options, err := ParsePolicy("attempts=4 backoff=exponential(1s,2,3s)")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, options.Attempts())
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}, backoff.Schedule(options.backoffStrategy, 3))
+
+	options, err = ParsePolicy("jitter=20% backoff=fixed(1s) max-backoff=1100ms attempts=unlimited max-elapsed=1m")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, options.Attempts())
+	assert.Equal(t, time.Minute, options.MaxElapsedTime())
+	for _, d := range backoff.Schedule(options.backoffStrategy, 10) {
+		assert.GreaterOrEqual(t, d, time.Second)
+		assert.LessOrEqual(t, d, 1100*time.Millisecond)
+	}
+
+	options, err = ParsePolicy("backoff=fixed(1s) max-backoff=0")
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Duration{time.Second, time.Second, time.Second}, backoff.Schedule(options.backoffStrategy, 3))
+
+	options, err = ParsePolicy("backoff=none attempts=2")
+	assert.NoError(t, err)
+	assert.False(t, options.HasBackoff())
+
+	for _, policy := range []string{
+		"attempts",
+		"attempts=many",
+		"retries=5",
+		"backoff=exponential(1s,2)",
+		"backoff=fixed(soon)",
+		"backoff=fixed(1s",
+		"backoff=unknown(1s)",
+		"jitter=x%",
+	} {
+		_, err = ParsePolicy(policy)
+		assert.ErrorIs(t, err, ErrInvalidPolicy, policy)
+	}
+	_, err = ParsePolicy("backoff=exponential(10s,2,1s)")
+	assert.ErrorIs(t, err, ErrInvalidOptions)
+	_, err = ParsePolicy("attempts=unlimited backoff=none")
+	assert.ErrorIs(t, err, ErrInvalidOptions)
+}
+
+func TestPolicyRegistry(t *testing.T) {
+	RegisterPolicy("test-db", NewOptions(WithAttempts(3), WithNoBackoff()))
+	RegisterPolicy("test-http", NewOptions(WithAttempts(2)))
+	assert.Subset(t, Policies(), []string{"test-db", "test-http"})
+
+	i := 0
+	err := DoWithOptions(func() error {
+		i++
+		return errFailed
+	}, Policy("test-db"))
+	assert.ErrorIs(t, err, ErrRetryAttemptsExceed)
+	assert.Equal(t, 3, i)
+
+	options, ok := LookupPolicy("test-http")
+	assert.True(t, ok)
+	assert.Equal(t, 2, options.Attempts())
+	assert.True(t, options.HasBackoff())
+
+	_, ok = LookupPolicy("test-unknown")
+	assert.False(t, ok)
+	assert.Panics(t, func() {
+		Policy("test-unknown")
+	})
+}
+
+func TestDynamicOptions(t *testing.T) {
+	dynamic := NewDynamicOptions(NewOptions(WithAttempts(10), WithNoBackoff()))
+	i := 0
+	err := Do(func() error {
+		i++
+		if i == 2 {
+			dynamic.Update(func(options Options) Options {
+				return options.With(WithAttempts(3))
+			})
+		}
+		return errFailed
+	}, WithDynamicOptions(dynamic))
+	assert.ErrorIs(t, err, ErrRetryAttemptsExceed)
+	assert.Equal(t, 3, i)
+	assert.Equal(t, 3, dynamic.Load().Attempts())
+
+	dynamic.Store(NewOptions(WithAttempts(1)))
+	i = 0
+	err = Do(func() error {
+		i++
+		return errFailed
+	}, WithDynamicOptions(dynamic))
+	assert.ErrorIs(t, err, ErrRetryAttemptsExceed)
+	assert.Equal(t, 1, i)
+}
+
+func TestDoRetryWithTimeout(t *testing.T) {
+	i := 0
+	start := time.Now()
+	err := Do(func() error {
+		i++
+		return errFailed
+	}, WithUnlimitedAttempts(), WithFixedBackoff(30*time.Millisecond), WithTimeout(100*time.Millisecond))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, err, errFailed)
+	assert.Less(t, time.Since(start), 200*time.Millisecond)
+	assert.GreaterOrEqual(t, i, 3)
+
+	err = DoCtx(context.Background(), func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithTimeout(10*time.Millisecond))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestDoRetryMaxTotalBackoff(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	i := 0
+	err := Do(func() error {
+		i++
+		clock.now = clock.now.Add(time.Hour)
+		return errFailed
+	}, WithClock(clock), WithUnlimitedAttempts(), WithBackoff(backoff.NewExponentialBackoff(time.Second, 2, 0)), WithMaxTotalBackoff(10*time.Second))
+	assert.ErrorIs(t, err, ErrMaxTotalBackoffExceed)
+	assert.Equal(t, 4, i)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}, clock.sleeps)
+}
+
+func TestDoRetryDeadlineBackoff(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	var backoffs []time.Duration
+	err := DoCtx(ctx, func(_ context.Context) error {
+		return errFailed
+	}, WithAttempts(3),
+		WithDeadlineBackoff(backoff.NewDeadlineSpread(backoff.NewFixedBackoff(time.Hour))),
+		WithMaxBackoff(time.Second),
+		WithOnRetryInfo(func(_ context.Context, info RetryInfo) {
+			backoffs = append(backoffs, info.Backoff)
+		}))
+	assert.ErrorIs(t, err, ErrRetryAttemptsExceed)
+	assert.Len(t, backoffs, 2)
+	for _, d := range backoffs {
+		assert.Greater(t, d, 50*time.Millisecond)
+		assert.LessOrEqual(t, d, 100*time.Millisecond)
+	}
+
+	// Without deadline, the fallback is used, and decorators still apply.
+	options := NewOptions(WithDeadlineBackoff(backoff.NewDeadlineSpread(backoff.NewFixedBackoff(time.Hour))), WithMaxBackoff(time.Second))
+	assert.Equal(t, time.Second, options.backoffStrategy(errFailed, 1))
+	assert.Nil(t, NewOptions(WithOptions(options), WithFixedBackoff(time.Second)).deadlineBackoff)
+}
+
+type countingLimiter struct {
+	waits int
+	limit int
+}
+
+func (l *countingLimiter) Wait(_ context.Context) error {
+	l.waits++
+	if l.waits > l.limit {
+		return errors.New("limit exceeded")
+	}
+	return nil
+}
+
+func TestDoRetryWithLimiter(t *testing.T) {
+	limiter := &countingLimiter{limit: 10}
+	i := 0
+	err := Do(func() error {
+		i++
+		if i < 3 {
+			return errFailed
+		}
+		return nil
+	}, WithLimiter(limiter), WithNoBackoff())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, limiter.waits)
+
+	limiter = &countingLimiter{limit: 2}
+	i = 0
+	err = Do(func() error {
+		i++
+		return errFailed
+	}, WithLimiter(limiter), WithNoBackoff())
+	assert.ErrorContains(t, err, "limit exceeded")
+	assert.Equal(t, 2, i)
+}
+
+func TestDoRetryConcurrencyLimit(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	limit := WithConcurrencyLimit(1, BulkheadFailFast)
+	done := make(chan error)
+	go func() {
+		done <- Do(func() error {
+			close(started)
+			<-release
+			return nil
+		}, limit)
+	}()
+	<-started
+
+	// The limit is shared by every retry using the same RetryOption.
+	err := Do(func() error {
+		return nil
+	}, limit)
+	assert.ErrorIs(t, err, ErrBulkheadFull)
+	assert.NoError(t, Do(func() error {
+		return nil
+	}, WithConcurrencyLimit(1, BulkheadFailFast)))
+
+	close(release)
+	assert.NoError(t, <-done)
+	assert.NoError(t, Do(func() error {
+		return nil
+	}, limit))
+}
+
+func TestDoRetryConcurrencyLimitWait(t *testing.T) {
+	limit := WithConcurrencyLimit(1, BulkheadWait)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go func() {
+		_ = Do(func() error {
+			close(started)
+			<-release
+			return nil
+		}, limit)
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := DoCtx(ctx, func(_ context.Context) error {
+		return nil
+	}, limit)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	time.AfterFunc(10*time.Millisecond, func() {
+		close(release)
+	})
+	assert.NoError(t, Do(func() error {
+		return nil
+	}, limit))
+}
+
+func TestGetHedged(t *testing.T) {
+	cnt := atomic.Int32{}
+	canceled := make(chan struct{})
+	v, err := GetHedged(context.Background(), func(ctx context.Context) (int32, error) {
+		n := cnt.Add(1)
+		if n == 1 {
+			<-ctx.Done()
+			close(canceled)
+			return 0, ctx.Err()
+		}
+		return n, nil
+	}, 10*time.Millisecond, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), v)
+	<-canceled
+
+	cnt.Store(0)
+	err = Hedge(context.Background(), func(_ context.Context) error {
+		cnt.Add(1)
+		time.Sleep(15 * time.Millisecond)
+		return errFailed
+	}, 5*time.Millisecond, 1, WithAttempts(2), WithNoBackoff())
+	assert.ErrorIs(t, err, ErrRetryAttemptsExceed)
+	assert.Equal(t, int32(4), cnt.Load())
+
+	// Panics of speculative attempts are recovered, and the hedge delay uses the clock.
+	cnt.Store(0)
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	release := make(chan struct{})
+	err = Hedge(context.Background(), func(ctx context.Context) error {
+		if cnt.Add(1) == 1 {
+			<-release
+		} else {
+			defer close(release)
+		}
+		panic("boom")
+	}, time.Hour, 1, WithAttempts(1), WithRecoverPanic(), WithClock(clock))
+	var panicErr *PanicError
+	assert.ErrorAs(t, err, &panicErr)
+	assert.Equal(t, []time.Duration{time.Hour}, clock.sleeps)
+
+	// Without WithRecoverPanic, the panic propagates to the caller instead of crashing the process.
+	assert.PanicsWithValue(t, "boom", func() {
+		_ = Hedge(context.Background(), func(_ context.Context) error {
+			panic("boom")
+		}, time.Hour, 1)
+	})
+
+	// A negative number of hedges only runs the operation.
+	cnt.Store(0)
+	err = Hedge(context.Background(), func(_ context.Context) error {
+		cnt.Add(1)
+		return nil
+	}, time.Millisecond, -2)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), cnt.Load())
+}
+
+func TestGetAny(t *testing.T) {
+	v, err := GetAny(context.Background(), []func(ctx context.Context) (string, error){
+		func(_ context.Context) (string, error) {
+			return "", errFailed
+		},
+		func(ctx context.Context) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+		func(ctx context.Context) (string, error) {
+			if attempt, _ := AttemptFromContext(ctx); attempt < 2 {
+				return "", errFailed
+			}
+			return "c", nil
+		},
+	}, WithNoBackoff())
+	assert.NoError(t, err)
+	assert.Equal(t, "c", v)
+
+	err = DoAny(context.Background(), []func(ctx context.Context) error{
+		func(_ context.Context) error {
+			return errFailed
+		},
+		func(_ context.Context) error {
+			return Unrecoverable(errFailed)
+		},
+	}, WithNoBackoff(), WithAttempts(2))
+	assert.ErrorIs(t, err, ErrRetryAttemptsExceed)
+	assert.ErrorContains(t, err, "operation 0")
+	assert.ErrorContains(t, err, "operation 1")
+
+	assert.ErrorIs(t, DoAny(context.Background(), nil), ErrNoOperation)
+}
+
+func TestGetWithFallbacks(t *testing.T) {
+	calls := make([]int, 3)
+	failing := func(i int) func(ctx context.Context) (string, error) {
+		return func(_ context.Context) (string, error) {
+			calls[i]++
+			return "", errFailed
+		}
+	}
+	v, source, err := GetWithFallbacks(context.Background(),
+		NewFallback(failing(0), WithNoBackoff(), WithAttempts(2)),
+		NewFallback(failing(1), WithNoBackoff(), WithAttempts(3)),
+		NewFallback(func(_ context.Context) (string, error) {
+			calls[2]++
+			return "remote", nil
+		}),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, "remote", v)
+	assert.Equal(t, 2, source)
+	assert.Equal(t, []int{2, 3, 1}, calls)
+
+	_, source, err = GetWithFallbacks(context.Background(),
+		NewFallback(failing(0), WithNoBackoff(), WithAttempts(1)),
+		NewFallback(failing(1), WithNoBackoff(), WithAttempts(1)),
+	)
+	assert.Equal(t, -1, source)
+	assert.ErrorIs(t, err, errFailed)
+	assert.ErrorContains(t, err, "operation 1")
+}
+
+func TestMapEach(t *testing.T) {
+	results, errs := MapEach(context.Background(), []string{"a", "bb", "ccc"}, func(ctx context.Context, item string) (int, error) {
+		if attempt, _ := AttemptFromContext(ctx); item == "bb" && attempt < 2 {
+			return 0, errFailed
+		}
+		if item == "ccc" {
+			return 0, errFailed
+		}
+		return len(item), nil
+	}, WithNoBackoff(), WithAttempts(2), WithParallelism(2))
+	assert.Equal(t, []int{1, 2, 0}, results)
+	assert.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[2], ErrRetryAttemptsExceed)
+
+	cnt := atomic.Int32{}
+	errs = Each(ContextWithBudget(context.Background(), 1), []int{1, 2, 3}, func(_ context.Context, _ int) error {
+		cnt.Add(1)
+		return errFailed
+	}, WithNoBackoff(), WithParallelism(1))
+	assert.Len(t, errs, 3)
+	assert.Equal(t, int32(4), cnt.Load())
+}
+
+func TestGroup(t *testing.T) {
+	g := NewGroup(context.Background(), WithNoBackoff(), WithAttempts(2), WithParallelism(2))
+	cnt := atomic.Int32{}
+	for i := 0; i < 5; i++ {
+		g.Go(func(ctx context.Context) error {
+			cnt.Add(1)
+			if attempt, _ := AttemptFromContext(ctx); attempt < 2 || i == 3 {
+				return errFailed
+			}
+			return nil
+		})
+	}
+	err := g.Wait()
+	assert.ErrorIs(t, err, ErrRetryAttemptsExceed)
+	assert.ErrorContains(t, err, "task 3")
+	assert.NotContains(t, err.Error(), "task 1")
+	assert.Equal(t, int32(10), cnt.Load())
+	assert.Error(t, g.Context().Err())
+
+	// Running out of attempts does not cancel the group.
+	gaveUp := make(chan struct{})
+	g = NewGroup(context.Background(), WithNoBackoff(), WithAttempts(2))
+	g.SetCancelOnUnrecoverable(true)
+	g.Go(func(ctx context.Context) error {
+		if attempt, _ := AttemptFromContext(ctx); attempt == 2 {
+			defer close(gaveUp)
+		}
+		return errFailed
+	})
+	g.Go(func(ctx context.Context) error {
+		<-gaveUp
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+			return nil
+		}
+	})
+	err = g.Wait()
+	assert.ErrorIs(t, err, ErrRetryAttemptsExceed)
+	assert.NotErrorIs(t, err, context.Canceled)
+
+	g = NewGroup(context.Background(), WithNoBackoff())
+	g.SetCancelOnUnrecoverable(true)
+	g.Go(func(_ context.Context) error {
+		return Unrecoverable(errFailed)
+	})
+	g.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	err = g.Wait()
+	assert.ErrorIs(t, err, errFailed)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestGoGet(t *testing.T) {
+	i := 0
+	f := GoGet(func() (int, error) {
+		i++
+		if i < 3 {
+			return 0, errFailed
+		}
+		return i, nil
+	}, WithNoBackoff())
+	<-f.Done()
+	v, err := f.Result()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, v)
+
+	d := GoDo(func() error {
+		return errFailed
+	}, WithNoBackoff(), WithAttempts(2))
+	assert.ErrorIs(t, d.Err(), ErrRetryAttemptsExceed)
+}
+
+func TestScheduler(t *testing.T) {
+	s := NewScheduler()
+	cnt := atomic.Int32{}
+	done := make(chan error, 1)
+	task := s.Schedule(func(ctx context.Context) error {
+		cnt.Add(1)
+		if attempt, _ := AttemptFromContext(ctx); attempt < 3 {
+			return errFailed
+		}
+		return nil
+	}, func(err error) {
+		done <- err
+	}, WithFixedBackoff(5*time.Millisecond))
+
+	select {
+	case <-task.Done():
+		t.Fatal("task ran before Start")
+	case <-time.After(20 * time.Millisecond):
+	}
+	s.Start()
+	assert.NoError(t, <-done)
+	assert.NoError(t, task.Err())
+	assert.Equal(t, int32(3), cnt.Load())
+
+	failed := s.Schedule(func(_ context.Context) error {
+		return errFailed
+	}, nil, WithNoBackoff(), WithAttempts(2))
+	assert.ErrorIs(t, failed.Err(), ErrRetryAttemptsExceed)
+
+	canceled := s.Schedule(func(_ context.Context) error {
+		return errFailed
+	}, nil, WithUnlimitedAttempts(), WithFixedBackoff(time.Hour))
+	time.Sleep(10 * time.Millisecond)
+	canceled.Cancel()
+	assert.ErrorIs(t, canceled.Err(), context.Canceled)
+	assert.ErrorIs(t, canceled.Err(), errFailed)
+
+	pending := s.Schedule(func(_ context.Context) error {
+		return errFailed
+	}, nil, WithUnlimitedAttempts(), WithFixedBackoff(time.Hour))
+	s.Stop()
+	assert.ErrorIs(t, pending.Err(), context.Canceled)
+	assert.ErrorIs(t, s.Schedule(func(_ context.Context) error {
+		return nil
+	}, nil).Err(), context.Canceled)
+}
+
+func TestSchedulerOptions(t *testing.T) {
+	s := NewScheduler()
+	s.Start()
+	defer s.Stop()
+
+	panicked := s.Schedule(func(_ context.Context) error {
+		panic("boom")
+	}, nil, WithNoBackoff(), WithAttempts(3))
+	var panicErr *PanicError
+	assert.ErrorAs(t, panicked.Err(), &panicErr)
+	assert.Equal(t, "boom", panicErr.Value)
+
+	cnt := atomic.Int32{}
+	recovered := s.Schedule(func(_ context.Context) error {
+		if cnt.Add(1) < 2 {
+			panic("boom")
+		}
+		return nil
+	}, nil, WithNoBackoff(), WithAttempts(3), WithRecoverPanic())
+	assert.NoError(t, recovered.Err())
+	assert.Equal(t, int32(2), cnt.Load())
+
+	limited := s.Schedule(func(_ context.Context) error {
+		return errFailed
+	}, nil, WithUnlimitedAttempts(), WithFixedBackoff(time.Millisecond), WithMaxTotalBackoff(3*time.Millisecond))
+	assert.ErrorIs(t, limited.Err(), ErrMaxTotalBackoffExceed)
+
+	assert.ErrorIs(t, s.Schedule(func(_ context.Context) error {
+		return nil
+	}, nil, WithLimiter(&countingLimiter{limit: 10})).Err(), ErrInvalidOptions)
+	assert.ErrorIs(t, s.Schedule(func(_ context.Context) error {
+		return nil
+	}, nil, WithConcurrencyLimit(1, BulkheadWait)).Err(), ErrInvalidOptions)
+	assert.ErrorIs(t, s.Schedule(func(_ context.Context) error {
+		return nil
+	}, nil, WithClock(&fakeClock{})).Err(), ErrInvalidOptions)
+
+	// The task completes when the parent context is canceled, without waiting for the backoff.
+	ctx, cancel := context.WithCancel(context.Background())
+	waiting := s.Schedule(func(_ context.Context) error {
+		return errFailed
+	}, nil, WithContext(ctx), WithUnlimitedAttempts(), WithFixedBackoff(time.Hour))
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case <-waiting.Done():
+	case <-time.After(time.Second):
+		t.Fatal("task not completed after the context was canceled")
+	}
+	assert.ErrorIs(t, waiting.Err(), context.Canceled)
+	assert.ErrorIs(t, waiting.Err(), errFailed)
+}
+
+func TestKeyedRetrier(t *testing.T) {
+	var backoffs []time.Duration
+	r := NewKeyed[string](WithAttempts(2),
+		WithBackoff(backoff.NewExponentialBackoff(time.Millisecond, 2, 0)),
+		WithOnRetryInfo(func(_ context.Context, info RetryInfo) {
+			backoffs = append(backoffs, info.Backoff)
+		}))
+
+	failing := func() error {
+		return errFailed
+	}
+	assert.ErrorIs(t, r.Do("a", failing), ErrRetryAttemptsExceed)
+	assert.ErrorIs(t, r.Do("a", failing), ErrRetryAttemptsExceed)
+	assert.Equal(t, 4, r.Failures("a"))
+	assert.ErrorIs(t, r.Do("b", failing), ErrRetryAttemptsExceed)
+	assert.Equal(t, []time.Duration{time.Millisecond, 4 * time.Millisecond, time.Millisecond}, backoffs)
+
+	// A success resets the failures of the key.
+	assert.NoError(t, r.Do("a", func() error {
+		return nil
+	}))
+	assert.Equal(t, 0, r.Failures("a"))
+	assert.Equal(t, 2, r.Failures("b"))
+	r.Reset("b")
+	assert.Equal(t, 0, r.Failures("b"))
+}
+
+func TestCached(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	i := 0
+	fail := false
+	get := Cached(func() (int, error) {
+		i++
+		if fail {
+			return 0, errFailed
+		}
+		return i, nil
+	}, time.Minute, WithClock(clock), WithAttempts(2), WithNoBackoff())
+
+	v, err := get()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, v)
+	v, _ = get()
+	assert.Equal(t, 1, v)
+
+	clock.now = clock.now.Add(time.Minute)
+	v, _ = get()
+	assert.Equal(t, 2, v)
+
+	clock.now = clock.now.Add(time.Minute)
+	fail = true
+	_, err = get()
+	assert.ErrorIs(t, err, ErrRetryAttemptsExceed)
+	assert.Equal(t, 4, i)
+
+	// The stale value is served immediately while a single refresh retries in the background.
+	calls := atomic.Int32{}
+	release := make(chan struct{})
+	refreshed := make(chan struct{})
+	stale := Cached(func() (int, error) {
+		n := calls.Add(1)
+		if n == 1 {
+			return 1, nil
+		}
+		<-release
+		if n == 2 {
+			return 0, errFailed
+		}
+		defer close(refreshed)
+		return int(n), nil
+	}, time.Minute, WithClock(clock), WithAttempts(2), WithNoBackoff(), WithServeStale())
+	v, _ = stale()
+	assert.Equal(t, 1, v)
+	clock.now = clock.now.Add(time.Minute)
+	for range 3 {
+		v, err = stale()
+		assert.NoError(t, err)
+		assert.Equal(t, 1, v)
+	}
+	close(release)
+	<-refreshed
+	assert.Eventually(t, func() bool {
+		v, _ := stale()
+		return v == 3
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestCachedStalePanic(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	calls := atomic.Int32{}
+	get := Cached(func() (int, error) {
+		n := calls.Add(1)
+		if n == 2 {
+			panic("boom")
+		}
+		return int(n), nil
+	}, time.Minute, WithClock(clock), WithAttempts(1), WithServeStale())
+	v, _ := get()
+	assert.Equal(t, 1, v)
+
+	// The panicking background refresh does not crash the process, and a later call refreshes again.
+	clock.now = clock.now.Add(time.Minute)
+	assert.Eventually(t, func() bool {
+		v, err := get()
+		return err == nil && v == 3
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,62 @@
+// Package trybudget provides a retry budget shared by multiple retry loops,
+// implementing the retry throttling of gRPC, to prevent retry storms during outages.
+//
+// The budget starts with maxTokens tokens. Every failed attempt removes a token,
+// and every successful attempt adds tokenRatio tokens, up to maxTokens.
+// Retries are allowed only while there are more than half of maxTokens tokens,
+// so once too many attempts fail, errors are returned immediately until enough attempts succeed.
+package trybudget
+
+import (
+	"github.com/mawngo/go-try"
+	"sync"
+)
+
+var _ try.Budget = (*Budget)(nil)
+
+// Budget is a token bucket retry budget, see the package documentation.
+// Use it with try.WithBudget, the same Budget can be shared by multiple retry loops, Retrier, and goroutines.
+type Budget struct {
+	mu         sync.Mutex
+	maxTokens  float64
+	tokenRatio float64
+	tokens     float64
+}
+
+// New create a Budget with the given maximum number of tokens,
+// and the number of tokens added for each successful attempt, typically between 0 and 1.
+func New(maxTokens float64, tokenRatio float64) *Budget {
+	return &Budget{
+		maxTokens:  maxTokens,
+		tokenRatio: tokenRatio,
+		tokens:     maxTokens,
+	}
+}
+
+// AllowRetry implements try.Budget, report whether there are more than half of the maximum tokens.
+func (b *Budget) AllowRetry() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens > b.maxTokens/2
+}
+
+// RecordSuccess implements try.Budget, add tokenRatio tokens.
+func (b *Budget) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.tokens+b.tokenRatio, b.maxTokens)
+}
+
+// RecordFailure implements try.Budget, remove a token.
+func (b *Budget) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = max(b.tokens-1, 0)
+}
+
+// Tokens return the number of tokens left.
+func (b *Budget) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens
+}
@@ -0,0 +1,50 @@
+package trybudget
+
+import (
+	"errors"
+	"github.com/mawngo/go-try"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var errFailed = errors.New("failed")
+
+func TestBudget(t *testing.T) {
+	b := New(10, 0.5)
+	r := try.New(try.WithBudget(b), try.WithNoBackoff(), try.WithAttempts(3))
+
+	i := 0
+	err := r.Do(func() error {
+		i++
+		return errFailed
+	})
+	assert.ErrorIs(t, err, try.ErrRetryAttemptsExceed)
+	assert.Equal(t, 3, i)
+	assert.Equal(t, 7.0, b.Tokens())
+
+	// The budget is shared, the next loop stops as soon as half of the tokens are used.
+	i = 0
+	err = r.Do(func() error {
+		i++
+		return errFailed
+	})
+	assert.ErrorIs(t, err, try.ErrRetryBudgetExceed)
+	assert.ErrorIs(t, err, errFailed)
+	assert.Equal(t, 2, i)
+	assert.Equal(t, 5.0, b.Tokens())
+
+	i = 0
+	err = r.Do(func() error {
+		i++
+		return errFailed
+	})
+	assert.ErrorIs(t, err, try.ErrRetryBudgetExceed)
+	assert.Equal(t, 1, i)
+
+	// Successes refill the budget.
+	for range 4 {
+		assert.NoError(t, r.Do(func() error { return nil }))
+	}
+	assert.Equal(t, 6.0, b.Tokens())
+	assert.True(t, b.AllowRetry())
+}
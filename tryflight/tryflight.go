@@ -0,0 +1,89 @@
+// Package tryflight deduplicates concurrent retries of the same logical operation,
+// so callers retrying the same key share one in-flight retry and all receive its result,
+// preventing duplicate retry storms, similar to golang.org/x/sync/singleflight.
+package tryflight
+
+import (
+	"context"
+	"github.com/mawngo/go-try"
+	"runtime/debug"
+	"sync"
+)
+
+// Group deduplicates retries by key. The zero value is ready to use.
+// A Group is safe for concurrent use.
+type Group[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*call[V]
+}
+
+type call[V any] struct {
+	done chan struct{}
+	v    V
+	err  error
+}
+
+// Get performs the given operation with retry, unless a retry for the same key is in flight,
+// in which case it waits for that retry and return its result.
+// See GetWithOptions.
+func (g *Group[K, V]) Get(ctx context.Context, key K, op func(ctx context.Context) (V, error), retryOptions ...try.RetryOption) (v V, shared bool, err error) {
+	option := try.NewOptions(retryOptions...)
+	return g.GetWithOptions(ctx, key, op, option)
+}
+
+// GetWithOptions performs the given operation with retry based on the options,
+// unless a retry for the same key is in flight, in which case it waits for that retry and return its result.
+// The shared is true if the result comes from a retry started by another caller.
+// The retry runs using the context of the caller that started it,
+// other callers stop waiting and return their context error when their context is done.
+// If the operation panics, the panic propagates to the caller that started the retry,
+// and the other callers return a try.PanicError.
+func (g *Group[K, V]) GetWithOptions(ctx context.Context, key K, op func(ctx context.Context) (V, error), options try.Options) (v V, shared bool, err error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[K]*call[V])
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		select {
+		case <-c.done:
+			return c.v, true, c.err
+		case <-ctx.Done():
+			var empty V
+			return empty, true, ctx.Err()
+		}
+	}
+	c := &call[V]{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	returned := false
+	defer func() {
+		// If the operation panicked, waiting callers receive the panic as a try.PanicError,
+		// and the panic continues in the caller that started the retry.
+		var r any
+		if !returned {
+			r = recover()
+			c.err = &try.PanicError{Value: r, Stack: debug.Stack()}
+		}
+		g.mu.Lock()
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+		g.mu.Unlock()
+		close(c.done)
+		if !returned {
+			panic(r)
+		}
+	}()
+	c.v, c.err = try.GetCtxWithOptions(ctx, op, options)
+	returned = true
+	return c.v, false, c.err
+}
+
+// Forget the in-flight retry of the key, so the next call starts a new retry instead of waiting for it.
+func (g *Group[K, V]) Forget(key K) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.calls, key)
+}
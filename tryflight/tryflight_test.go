@@ -0,0 +1,89 @@
+package tryflight
+
+import (
+	"context"
+	"errors"
+	"github.com/mawngo/go-try"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errFailed = errors.New("failed")
+
+func TestGroup(t *testing.T) {
+	g := Group[string, int]{}
+	cnt := atomic.Int32{}
+	release := make(chan struct{})
+	op := func(ctx context.Context) (int, error) {
+		cnt.Add(1)
+		if attempt, _ := try.AttemptFromContext(ctx); attempt < 2 {
+			return 0, errFailed
+		}
+		<-release
+		return 42, nil
+	}
+
+	wg := sync.WaitGroup{}
+	sharedCnt := atomic.Int32{}
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, shared, err := g.Get(context.Background(), "key", op, try.WithNoBackoff())
+			assert.NoError(t, err)
+			assert.Equal(t, 42, v)
+			if shared {
+				sharedCnt.Add(1)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(2), cnt.Load())
+	assert.Equal(t, int32(4), sharedCnt.Load())
+
+	// A new call after completion starts a new retry.
+	_, shared, err := g.Get(context.Background(), "key", func(_ context.Context) (int, error) {
+		return 0, errFailed
+	}, try.WithNoBackoff(), try.WithAttempts(1))
+	assert.False(t, shared)
+	assert.ErrorIs(t, err, errFailed)
+}
+
+func TestGroupPanic(t *testing.T) {
+	g := Group[string, int]{}
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	go func() {
+		defer func() {
+			assert.Equal(t, "boom", recover())
+		}()
+		_, _, _ = g.Get(context.Background(), "key", func(_ context.Context) (int, error) {
+			close(started)
+			<-release
+			panic("boom")
+		})
+	}()
+	<-started
+
+	done := make(chan error)
+	go func() {
+		_, shared, err := g.Get(context.Background(), "key", func(_ context.Context) (int, error) {
+			return 42, nil
+		})
+		assert.True(t, shared)
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	err := <-done
+	var panicErr *try.PanicError
+	assert.ErrorAs(t, err, &panicErr)
+	assert.Equal(t, "boom", panicErr.Value)
+}
@@ -0,0 +1,111 @@
+package trygrpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/mawngo/go-try"
+	"github.com/mawngo/go-try/backoff"
+	"google.golang.org/grpc/codes"
+	"time"
+)
+
+// ErrInvalidServiceConfig is returned by OptionsFromServiceConfig when the retry policy is malformed.
+var ErrInvalidServiceConfig = errors.New("invalid service config")
+
+// maxServiceConfigAttempts is the limit of maxAttempts, larger values are treated as this limit by gRPC.
+const maxServiceConfigAttempts = 5
+
+type retryPolicy struct {
+	MaxAttempts          int          `json:"maxAttempts"`
+	InitialBackoff       string       `json:"initialBackoff"`
+	MaxBackoff           string       `json:"maxBackoff"`
+	BackoffMultiplier    float64      `json:"backoffMultiplier"`
+	RetryableStatusCodes []codes.Code `json:"retryableStatusCodes"`
+}
+
+type serviceConfig struct {
+	RetryPolicy  *retryPolicy `json:"retryPolicy"`
+	MethodConfig []struct {
+		RetryPolicy *retryPolicy `json:"retryPolicy"`
+	} `json:"methodConfig"`
+}
+
+// OptionsFromServiceConfig convert a gRPC retryPolicy into Options, starting from the defaults of try.NewOptions.
+// The config is either the retryPolicy block, a method config containing it,
+// or a service config, in which case the retryPolicy of the first method config having one is used, for example:
+//
+//	{
+//	  "maxAttempts": 4,
+//	  "initialBackoff": "0.1s",
+//	  "maxBackoff": "1s",
+//	  "backoffMultiplier": 2,
+//	  "retryableStatusCodes": ["UNAVAILABLE", "RESOURCE_EXHAUSTED"]
+//	}
+//
+// Like gRPC, maxAttempts larger than 5 are treated as 5,
+// and the backoff is randomized between 0 and the exponential backoff, see backoff.NewExponentialFullJitterBackoff.
+// The returned Options retry the errors having one of the retryableStatusCodes, see Code.
+func OptionsFromServiceConfig(config []byte) (try.Options, error) {
+	policy, err := parseRetryPolicy(config)
+	if err != nil {
+		return try.Options{}, err
+	}
+	if policy.MaxAttempts < 2 {
+		return try.Options{}, fmt.Errorf("%w: maxAttempts must be greater than 1, got %d", ErrInvalidServiceConfig, policy.MaxAttempts)
+	}
+	initialBackoff, err := parseServiceConfigDuration("initialBackoff", policy.InitialBackoff)
+	if err != nil {
+		return try.Options{}, err
+	}
+	maxBackoff, err := parseServiceConfigDuration("maxBackoff", policy.MaxBackoff)
+	if err != nil {
+		return try.Options{}, err
+	}
+	if policy.BackoffMultiplier <= 0 {
+		return try.Options{}, fmt.Errorf("%w: backoffMultiplier must be positive, got %g", ErrInvalidServiceConfig, policy.BackoffMultiplier)
+	}
+	if len(policy.RetryableStatusCodes) == 0 {
+		return try.Options{}, fmt.Errorf("%w: retryableStatusCodes must not be empty", ErrInvalidServiceConfig)
+	}
+	options := try.NewOptions(
+		try.WithAttempts(min(policy.MaxAttempts, maxServiceConfigAttempts)),
+		try.WithBackoff(backoff.NewExponentialFullJitterBackoff(initialBackoff, policy.BackoffMultiplier, maxBackoff)),
+		try.WithRetryIf(Code(policy.RetryableStatusCodes[0], policy.RetryableStatusCodes[1:]...)),
+	)
+	if err := options.Validate(); err != nil {
+		return try.Options{}, err
+	}
+	return options, nil
+}
+
+func parseRetryPolicy(config []byte) (*retryPolicy, error) {
+	var cfg serviceConfig
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidServiceConfig, err)
+	}
+	if cfg.RetryPolicy != nil {
+		return cfg.RetryPolicy, nil
+	}
+	for _, method := range cfg.MethodConfig {
+		if method.RetryPolicy != nil {
+			return method.RetryPolicy, nil
+		}
+	}
+	var policy retryPolicy
+	if err := json.Unmarshal(config, &policy); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidServiceConfig, err)
+	}
+	return &policy, nil
+}
+
+func parseServiceConfigDuration(key string, value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s: %w", ErrInvalidServiceConfig, key, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%w: %s must be positive, got %s", ErrInvalidServiceConfig, key, value)
+	}
+	return d, nil
+}
@@ -0,0 +1,98 @@
+// Package trygrpc provides retrying gRPC client interceptors.
+//
+// It is a separate module, so the go-try module does not depend on gRPC.
+package trygrpc
+
+import (
+	"context"
+	"errors"
+	"github.com/mawngo/go-try"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"slices"
+)
+
+// DefaultCodes are the status codes retried by default.
+var DefaultCodes = []codes.Code{
+	codes.Unavailable,
+	codes.ResourceExhausted,
+}
+
+// Code return a try.ErrorMatcher that match errors having any of the given status codes.
+func Code(code codes.Code, others ...codes.Code) try.ErrorMatcher {
+	matched := append([]codes.Code{code}, others...)
+	return func(err error) bool {
+		var e interface{ GRPCStatus() *status.Status }
+		if !errors.As(err, &e) {
+			return false
+		}
+		return slices.Contains(matched, e.GRPCStatus().Code())
+	}
+}
+
+// UnaryClientInterceptor return a grpc.UnaryClientInterceptor that retry the calls.
+// See UnaryClientInterceptorWithOptions.
+func UnaryClientInterceptor(retryOptions ...try.RetryOption) grpc.UnaryClientInterceptor {
+	return UnaryClientInterceptorWithOptions(try.NewOptions(retryOptions...))
+}
+
+// UnaryClientInterceptorWithOptions return a grpc.UnaryClientInterceptor that retry the calls based on the options.
+// Errors having one of DefaultCodes are retried, unless a matcher is configured using try.WithRetryIf,
+// for example, try.WithRetryIf(Code(codes.Unavailable, codes.Aborted)).
+// The RetryInfo detail of the errors is honored, see try.WithRetryAfterHints.
+func UnaryClientInterceptorWithOptions(options try.Options) grpc.UnaryClientInterceptor {
+	options = clientOptions(options)
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return try.DoCtxWithOptions(ctx, func(ctx context.Context) error {
+			return withRetryInfo(invoker(ctx, method, req, reply, cc, opts...))
+		}, options)
+	}
+}
+
+// StreamClientInterceptor return a grpc.StreamClientInterceptor that retry creating the streams.
+// See StreamClientInterceptorWithOptions.
+func StreamClientInterceptor(retryOptions ...try.RetryOption) grpc.StreamClientInterceptor {
+	return StreamClientInterceptorWithOptions(try.NewOptions(retryOptions...))
+}
+
+// StreamClientInterceptorWithOptions return a grpc.StreamClientInterceptor that retry creating the streams based on the options,
+// matching the errors like UnaryClientInterceptorWithOptions.
+// Streams are only retried before the first message is sent, when they fail to be created.
+// Once created, the errors of the stream are returned as is.
+//
+// The stream outlives the retry, so the options must not cancel the context once the retry ends, such as try.WithTimeout does.
+func StreamClientInterceptorWithOptions(options try.Options) grpc.StreamClientInterceptor {
+	options = clientOptions(options)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return try.GetCtxWithOptions(ctx, func(ctx context.Context) (grpc.ClientStream, error) {
+			stream, err := streamer(ctx, desc, cc, method, opts...)
+			return stream, withRetryInfo(err)
+		}, options)
+	}
+}
+
+func clientOptions(options try.Options) try.Options {
+	if !options.HasRetryIf() {
+		options = options.With(try.WithRetryIf(Code(DefaultCodes[0], DefaultCodes[1:]...)))
+	}
+	return options.With(try.WithRetryAfterHints())
+}
+
+// withRetryInfo attach the delay of the RetryInfo detail of the error as a backoff hint.
+func withRetryInfo(err error) error {
+	if err == nil {
+		return nil
+	}
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	for _, detail := range s.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok && info.GetRetryDelay() != nil {
+			return try.WithRetryAfter(err, info.GetRetryDelay().AsDuration())
+		}
+	}
+	return err
+}
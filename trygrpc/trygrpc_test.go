@@ -0,0 +1,141 @@
+package trygrpc
+
+import (
+	"context"
+	"fmt"
+	"github.com/mawngo/go-try"
+	"github.com/mawngo/go-try/trymatch"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type healthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	calls    atomic.Int32
+	failures int32
+	code     codes.Code
+}
+
+func (s *healthServer) Check(context.Context, *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if s.calls.Add(1) <= s.failures {
+		st, _ := status.New(s.code, "failed").WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(0)})
+		return nil, st.Err()
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+func (s *healthServer) Watch(_ *grpc_health_v1.HealthCheckRequest, stream grpc.ServerStreamingServer[grpc_health_v1.HealthCheckResponse]) error {
+	return stream.Send(&grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING})
+}
+
+func dial(t *testing.T, server *healthServer, opts ...grpc.DialOption) grpc_health_v1.HealthClient {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(s, server)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	opts = append(opts,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}))
+	conn, err := grpc.NewClient("passthrough:///bufnet", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return grpc_health_v1.NewHealthClient(conn)
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	server := &healthServer{failures: 2, code: codes.Unavailable}
+	// The RetryInfo of the errors takes precedence over the configured backoff.
+	client := dial(t, server, grpc.WithUnaryInterceptor(UnaryClientInterceptor(try.WithFixedBackoff(time.Hour))))
+	resp, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.GetStatus())
+	assert.Equal(t, int32(3), server.calls.Load())
+
+	server = &healthServer{failures: 2, code: codes.InvalidArgument}
+	client = dial(t, server, grpc.WithUnaryInterceptor(UnaryClientInterceptor(try.WithNoBackoff())))
+	_, err = client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, int32(1), server.calls.Load())
+
+	server = &healthServer{failures: 2, code: codes.InvalidArgument}
+	client = dial(t, server, grpc.WithUnaryInterceptor(UnaryClientInterceptor(try.WithNoBackoff(), try.WithRetryIf(Code(codes.InvalidArgument)))))
+	_, err = client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), server.calls.Load())
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	var created atomic.Int32
+	failing := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if created.Add(1) < 3 {
+			return nil, status.Error(codes.Unavailable, "failed")
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+	client := dial(t, &healthServer{}, grpc.WithChainStreamInterceptor(StreamClientInterceptor(try.WithNoBackoff()), failing))
+	stream, err := client.Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err)
+	resp, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.GetStatus())
+	assert.Equal(t, int32(3), created.Load())
+}
+
+func TestOptionsFromServiceConfig(t *testing.T) {
+	options, err := OptionsFromServiceConfig([]byte(`{
+		"methodConfig": [{
+			"name": [{"service": "grpc.health.v1.Health"}],
+			"retryPolicy": {
+				"maxAttempts": 10,
+				"initialBackoff": "0.1s",
+				"maxBackoff": "1s",
+				"backoffMultiplier": 2,
+				"retryableStatusCodes": ["UNAVAILABLE", 4]
+			}
+		}]
+	}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 5, options.Attempts())
+	assert.True(t, options.HasRetryIf())
+
+	i := 0
+	err = try.DoWithOptions(func() error {
+		i++
+		if i == 1 {
+			return status.Error(codes.DeadlineExceeded, "failed")
+		}
+		return status.Error(codes.InvalidArgument, "failed")
+	}, options.With(try.WithNoBackoff()))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, 2, i)
+
+	_, err = OptionsFromServiceConfig([]byte(`{"maxAttempts": 3, "initialBackoff": "0.1s", "maxBackoff": "1s", "backoffMultiplier": 2, "retryableStatusCodes": []}`))
+	assert.ErrorIs(t, err, ErrInvalidServiceConfig)
+	_, err = OptionsFromServiceConfig([]byte(`{"maxAttempts": 3, "initialBackoff": "soon", "maxBackoff": "1s", "backoffMultiplier": 2, "retryableStatusCodes": ["UNAVAILABLE"]}`))
+	assert.ErrorIs(t, err, ErrInvalidServiceConfig)
+	_, err = OptionsFromServiceConfig([]byte(`{"maxAttempts": 3, "initialBackoff": "0.1s", "maxBackoff": "1s", "backoffMultiplier": 2, "retryableStatusCodes": ["SOMETIMES"]}`))
+	assert.ErrorIs(t, err, ErrInvalidServiceConfig)
+}
+
+func TestMatchGRPCCode(t *testing.T) {
+	err := fmt.Errorf("call: %w", status.Error(codes.Unavailable, "failed"))
+	assert.True(t, trymatch.GRPCCode(codes.Unavailable, codes.Aborted)(err))
+	assert.False(t, trymatch.GRPCCode(codes.Aborted)(err))
+}
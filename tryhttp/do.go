@@ -0,0 +1,55 @@
+package tryhttp
+
+import (
+	"context"
+	"github.com/mawngo/go-try"
+	"net/http"
+	"slices"
+)
+
+// AttemptHeader is the request header set by Do to the attempt number, starting from 1.
+const AttemptHeader = "X-Retry-Attempt"
+
+// StatusCodes return a predicate for DoWithOptions that retry responses having any of the given status codes.
+func StatusCodes(codes ...int) func(resp *http.Response) bool {
+	return func(resp *http.Response) bool {
+		return slices.Contains(codes, resp.StatusCode)
+	}
+}
+
+// Do send the request using the client with retry.
+// See DoWithOptions.
+func Do(ctx context.Context, client *http.Client, req *http.Request, retryOptions ...try.RetryOption) (*http.Response, error) {
+	return DoWithOptions(ctx, client, req, nil, try.NewOptions(retryOptions...))
+}
+
+// DoWithOptions send the request using the client, or http.DefaultClient if nil, with retry.
+// Non-2xx responses are retried if retryStatus return true, which defaults to StatusCodes(DefaultStatusCodes...) if nil.
+// The Retry-After header of the responses is honored, limited by the WithMaxBackoff of the options,
+// see try.WithRetryAfterHints,
+// and the AttemptHeader of each attempt is set to the attempt number.
+//
+// Unlike Transport, the request is retried regardless of its method.
+// Requests with a body are sent only once if their GetBody is not set.
+// The body of a retried response is drained and closed so the connection can be reused,
+// responses with a body larger than DefaultDrainLimit are returned as is instead of being retried.
+// When the retry gives up on a retryable status, the last response is returned without error.
+func DoWithOptions(ctx context.Context, client *http.Client, req *http.Request, retryStatus func(resp *http.Response) bool, options try.Options) (*http.Response, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if retryStatus == nil {
+		retryStatus = StatusCodes(DefaultStatusCodes...)
+	}
+	options = options.With(try.WithRetryAfterHints())
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		options = options.With(try.WithAttempts(1))
+	}
+	return send(req.WithContext(ctx), client.Do, config{
+		retryStatus: func(resp *http.Response) bool {
+			return (resp.StatusCode < 200 || resp.StatusCode > 299) && retryStatus(resp)
+		},
+		attemptHeader: true,
+		drainLimit:    DefaultDrainLimit,
+	}, options)
+}
@@ -0,0 +1,285 @@
+// Package tryhttp provides retries for HTTP clients.
+package tryhttp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/mawngo/go-try"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultStatusCodes are the response status codes retried by default.
+var DefaultStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// DefaultDrainLimit is the default maximum size of a response body read to reuse the connection before retrying.
+// See Transport.SetDrainLimit.
+const DefaultDrainLimit = 1 << 20
+
+// StatusError is the error of an attempt that received a retryable response status.
+// It carries the Retry-After header of the response as a backoff hint, see try.WithRetryAfterHints.
+type StatusError struct {
+	StatusCode int
+	retryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// HTTPStatusCode return the status code of the response, used by trymatch.HTTPStatus.
+func (e *StatusError) HTTPStatusCode() int {
+	return e.StatusCode
+}
+
+// RetryAfter implements backoff.RetryAfterHint, return -1 if the response has no valid Retry-After header.
+func (e *StatusError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// Transport is a http.RoundTripper that retries idempotent requests
+// on connection errors and on responses with a retryable status.
+type Transport struct {
+	base        http.RoundTripper
+	statusCodes []int
+	options     try.Options
+	drainLimit  int64
+	decorate    func(req *http.Request, attempt int)
+	hosts       map[string]try.Options
+	wildcards   []wildcardOptions
+}
+
+// wildcardOptions are the options of the hosts ending with suffix.
+type wildcardOptions struct {
+	suffix  string
+	options try.Options
+}
+
+var _ http.RoundTripper = (*Transport)(nil)
+
+// NewTransport create a Transport.
+// See NewTransportWithOptions.
+func NewTransport(base http.RoundTripper, statusCodes []int, retryOptions ...try.RetryOption) *Transport {
+	return NewTransportWithOptions(base, statusCodes, try.NewOptions(retryOptions...))
+}
+
+// NewTransportWithOptions create a Transport sending the requests using base, or http.DefaultTransport if nil,
+// and retrying them based on the options.
+// Responses with one of the given statusCodes are retried, DefaultStatusCodes are used if nil.
+// The Retry-After header of the responses is honored, limited by the WithMaxBackoff of the options,
+// see try.WithRetryAfterHints.
+//
+// Only idempotent requests are retried: GET, HEAD, OPTIONS, TRACE, PUT and DELETE requests,
+// and requests with an Idempotency-Key header. Requests with a body are retried only if their GetBody is set,
+// which is the case for requests created by http.NewRequest with a bytes or strings reader.
+// The body of a retried response is drained and closed so the connection can be reused,
+// responses with a body larger than the drain limit are returned as is instead of being retried, see SetDrainLimit.
+// When the retry gives up on a retryable status, the last response is returned without error.
+func NewTransportWithOptions(base http.RoundTripper, statusCodes []int, options try.Options) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if statusCodes == nil {
+		statusCodes = DefaultStatusCodes
+	}
+	return &Transport{
+		base:        base,
+		statusCodes: statusCodes,
+		options:     options.With(try.WithRetryAfterHints()),
+		drainLimit:  DefaultDrainLimit,
+	}
+}
+
+// SetDrainLimit set the maximum size of a response body read to reuse the connection before retrying,
+// DefaultDrainLimit by default. Responses with a larger body are returned as is instead of being retried.
+// It must be called before the Transport is used.
+func (t *Transport) SetDrainLimit(limit int64) {
+	t.drainLimit = max(limit, 0)
+}
+
+// SetRequestDecorator set a function called with the request of every attempt before it is sent,
+// for example, to set headers with the attempt number or a stable idempotency key.
+// The request is a clone carrying the context of the attempt, so it can be modified freely.
+// It must be called before the Transport is used.
+func (t *Transport) SetRequestDecorator(decorate func(req *http.Request, attempt int)) {
+	t.decorate = decorate
+}
+
+// SetHostOptions retry the requests to the given host based on the options instead of the options of the Transport,
+// so one Transport can retry internal services aggressively and third-party APIs conservatively.
+// The pattern is either a host name, such as "api.example.com", or a wildcard, such as "*.example.com",
+// matching every subdomain of example.com but not example.com itself. Ports are ignored.
+// An exact host takes precedence over wildcards, and longer wildcards take precedence over shorter ones.
+// It must be called before the Transport is used.
+func (t *Transport) SetHostOptions(pattern string, options try.Options) {
+	pattern = strings.ToLower(pattern)
+	options = options.With(try.WithRetryAfterHints())
+	if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+		t.wildcards = slices.DeleteFunc(t.wildcards, func(w wildcardOptions) bool {
+			return w.suffix == suffix
+		})
+		t.wildcards = append(t.wildcards, wildcardOptions{suffix: suffix, options: options})
+		slices.SortStableFunc(t.wildcards, func(a, b wildcardOptions) int {
+			return len(b.suffix) - len(a.suffix)
+		})
+		return
+	}
+	if t.hosts == nil {
+		t.hosts = make(map[string]try.Options)
+	}
+	t.hosts[pattern] = options
+}
+
+// hostOptions return the options used for the requests to the host of the url.
+func (t *Transport) hostOptions(u *url.URL) try.Options {
+	if len(t.hosts) == 0 && len(t.wildcards) == 0 {
+		return t.options
+	}
+	host := strings.ToLower(u.Hostname())
+	if options, ok := t.hosts[host]; ok {
+		return options
+	}
+	for _, w := range t.wildcards {
+		if strings.HasSuffix(host, w.suffix) {
+			return w.options
+		}
+	}
+	return t.options
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !retryable(req) {
+		return t.base.RoundTrip(req)
+	}
+	return send(req, t.base.RoundTrip, config{
+		retryStatus: StatusCodes(t.statusCodes...),
+		drainLimit:  t.drainLimit,
+		decorate:    t.decorate,
+	}, t.hostOptions(req.URL))
+}
+
+// config configures how send performs the attempts.
+type config struct {
+	// retryStatus report whether a response is retried.
+	retryStatus func(*http.Response) bool
+	// attemptHeader set the AttemptHeader of each attempt.
+	attemptHeader bool
+	// drainLimit is the maximum size of a retried response body.
+	drainLimit int64
+	// decorate the request of each attempt if not nil.
+	decorate func(req *http.Request, attempt int)
+}
+
+// send performs the request with retry, using roundTrip to send each attempt.
+// Responses matching retryStatus are drained and retried, the last of them is returned without error on giving up.
+func send(req *http.Request, roundTrip func(*http.Request) (*http.Response, error), c config, options try.Options) (*http.Response, error) {
+	var last *http.Response
+	resp, err := try.GetCtxWithOptions(req.Context(), func(ctx context.Context) (*http.Response, error) {
+		r, err := rewind(ctx, req, c)
+		if err != nil {
+			return nil, try.Unrecoverable(err)
+		}
+		resp, err := roundTrip(r)
+		if err != nil || !c.retryStatus(resp) {
+			return resp, err
+		}
+		if !drain(resp, c.drainLimit) {
+			return resp, nil
+		}
+		last = resp
+		return nil, &StatusError{StatusCode: resp.StatusCode, retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+	}, options)
+	// The last response is only returned when giving up on its status, not when the context is done.
+	var statusErr *StatusError
+	if err != nil && last != nil && req.Context().Err() == nil && errors.As(err, &statusErr) {
+		return last, nil
+	}
+	return resp, err
+}
+
+// retryable return whether the request is idempotent, and its body can be sent again.
+func retryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != "" || req.Header.Get("X-Idempotency-Key") != ""
+}
+
+// rewind return the request to send for the attempt, carrying the context of the attempt, with a fresh body,
+// the AttemptHeader set to the attempt number if configured, and decorated by the configured decorator.
+func rewind(ctx context.Context, req *http.Request, c config) (*http.Request, error) {
+	attempt, _ := try.AttemptFromContext(ctx)
+	rewindBody := attempt > 1 && req.GetBody != nil
+	if !rewindBody && !c.attemptHeader && c.decorate == nil {
+		return req.WithContext(ctx), nil
+	}
+	r := req.Clone(ctx)
+	if rewindBody {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	if c.attemptHeader {
+		r.Header.Set(AttemptHeader, strconv.Itoa(attempt))
+	}
+	if c.decorate != nil {
+		c.decorate(r, attempt)
+	}
+	return r, nil
+}
+
+// drain read the body of the response into memory and close it, so the connection can be reused.
+// Return false if the body is larger than limit, in which case the response is left readable as is.
+func drain(resp *http.Response, limit int64) bool {
+	buf, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err == nil && int64(len(buf)) > limit {
+		resp.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(buf), resp.Body), Closer: resp.Body}
+		return false
+	}
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(buf))
+	return true
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// parseRetryAfter parse a Retry-After header, either in seconds or a http date, return -1 if invalid.
+// Delays too large for a time.Duration are limited to the maximum time.Duration.
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return -1
+	}
+	if seconds, err := strconv.ParseInt(header, 10, 64); err == nil && seconds >= 0 {
+		if seconds > int64(math.MaxInt64/time.Second) {
+			return math.MaxInt64
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return -1
+}
@@ -0,0 +1,257 @@
+package tryhttp
+
+import (
+	"context"
+	"github.com/mawngo/go-try"
+	"github.com/mawngo/go-try/trymatch"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTransport(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, nil, try.WithFixedBackoff(time.Hour))}
+	req, _ := http.NewRequest(http.MethodPut, server.URL, strings.NewReader("hello"))
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "hello", string(body))
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestTransportGiveUp(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte("slow down"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, nil, try.WithNoBackoff(), try.WithAttempts(2))}
+	resp, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "slow down", string(body))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestTransportNotIdempotent(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, nil, try.WithNoBackoff(), try.WithAttempts(3))}
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("hello"))
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("hello"))
+	req.Header.Set("Idempotency-Key", "1")
+	resp, err = client.Do(req)
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, int32(4), calls.Load())
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert.Equal(t, 3*time.Second, parseRetryAfter("3"))
+	assert.Equal(t, time.Duration(-1), parseRetryAfter(""))
+	assert.Equal(t, time.Duration(-1), parseRetryAfter("soon"))
+	assert.Equal(t, time.Duration(math.MaxInt64), parseRetryAfter("99999999999999999"))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)))
+	d := parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	assert.True(t, d > 50*time.Second && d <= time.Minute)
+}
+
+func TestDo(t *testing.T) {
+	var attempts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts = append(attempts, r.Header.Get(AttemptHeader))
+		if len(attempts) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("hello"))
+	resp, err := DoWithOptions(context.Background(), nil, req, StatusCodes(http.StatusInternalServerError), try.NewOptions(try.WithNoBackoff()))
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, []string{"1", "2", "3"}, attempts)
+	assert.Empty(t, req.Header.Get(AttemptHeader))
+
+	attempts = nil
+	req, _ = http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err = Do(context.Background(), server.Client(), req, try.WithNoBackoff())
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, []string{"1"}, attempts)
+}
+
+func TestMatchStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var errs []error
+	client := &http.Client{Transport: NewTransport(nil, nil, try.WithNoBackoff(), try.WithAttempts(2), try.WithOnRetry(func(_ context.Context, err error, _ int) {
+		errs = append(errs, err)
+	}))}
+	resp, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Len(t, errs, 1)
+	assert.True(t, trymatch.HTTPStatus(http.StatusServiceUnavailable)(errs[0]))
+}
+
+func TestTransportRetryAfterLimit(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	// The hint does not exceed the max backoff.
+	client := &http.Client{Transport: NewTransport(nil, nil, try.WithFixedBackoff(time.Millisecond), try.WithMaxBackoff(10*time.Millisecond), try.WithAttempts(3))}
+	start := time.Now()
+	resp, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(3), calls.Load())
+
+	// The context error is returned instead of the last response when the context is done.
+	client = &http.Client{Transport: NewTransport(nil, nil)}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err = client.Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Nil(t, resp)
+}
+
+func TestTransportDrainLimit(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("maintenance"))
+	}))
+	defer server.Close()
+
+	transport := NewTransport(nil, nil, try.WithNoBackoff(), try.WithAttempts(3))
+	transport.SetDrainLimit(4)
+	client := &http.Client{Transport: transport}
+	resp, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "maintenance", string(body))
+	assert.Equal(t, int32(1), calls.Load())
+
+	transport.SetDrainLimit(DefaultDrainLimit)
+	resp, err = client.Get(server.URL)
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, int32(4), calls.Load())
+}
+
+func TestTransportRequestDecorator(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if len(keys) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	var attempts []int
+	transport := NewTransport(nil, nil, try.WithNoBackoff(), try.WithTimeout(time.Minute))
+	transport.SetRequestDecorator(func(req *http.Request, attempt int) {
+		// The request carries the context of the attempt.
+		fromCtx, _ := try.AttemptFromContext(req.Context())
+		_, hasDeadline := req.Context().Deadline()
+		assert.True(t, hasDeadline)
+		attempts = append(attempts, fromCtx)
+		req.Header.Set("Idempotency-Key", "key-"+strconv.Itoa(attempt))
+	})
+	client := &http.Client{Transport: transport}
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []int{1, 2, 3}, attempts)
+	assert.Equal(t, []string{"key-1", "key-2", "key-3"}, keys)
+	assert.Empty(t, req.Header.Get("Idempotency-Key"))
+}
+
+func TestTransportHostOptions(t *testing.T) {
+	transport := NewTransport(nil, nil, try.WithAttempts(1))
+	transport.SetHostOptions("*.example.com", try.NewOptions(try.WithAttempts(3)))
+	transport.SetHostOptions("*.internal.example.com", try.NewOptions(try.WithAttempts(10)))
+	transport.SetHostOptions("API.example.com", try.NewOptions(try.WithAttempts(5)))
+
+	for host, attempts := range map[string]int{
+		"api.example.com:8080":    5,
+		"www.example.com":         3,
+		"db.internal.example.com": 10,
+		"example.com":             1,
+		"other.org":               1,
+	} {
+		u, _ := url.Parse("http://" + host + "/path")
+		assert.Equal(t, attempts, transport.hostOptions(u).Attempts(), host)
+	}
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	transport = NewTransport(nil, nil, try.WithNoBackoff(), try.WithAttempts(1))
+	transport.SetHostOptions("127.0.0.1", try.NewOptions(try.WithNoBackoff(), try.WithAttempts(3)))
+	client := &http.Client{Transport: transport}
+	resp, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, int32(3), calls.Load())
+}
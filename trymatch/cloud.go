@@ -0,0 +1,78 @@
+package trymatch
+
+import (
+	"net/http"
+	"reflect"
+	"slices"
+)
+
+// AWSThrottlingCodes are the error codes of the AWS SDK errors caused by throttling.
+var AWSThrottlingCodes = []string{
+	"Throttling",
+	"ThrottlingException",
+	"ThrottledException",
+	"RequestThrottledException",
+	"TooManyRequestsException",
+	"ProvisionedThroughputExceededException",
+	"TransactionInProgressException",
+	"RequestLimitExceeded",
+	"BandwidthLimitExceeded",
+	"LimitExceededException",
+	"RequestThrottled",
+	"SlowDown",
+	"PriorRequestNotComplete",
+	"EC2ThrottledException",
+}
+
+// grpcResourceExhausted is the gRPC RESOURCE_EXHAUSTED status code.
+const grpcResourceExhausted uint32 = 8
+
+type errorCoder interface {
+	ErrorCode() string
+}
+
+// AWSThrottling is a try.ErrorMatcher that match AWS SDK errors having one of AWSThrottlingCodes.
+// The code is read from the ErrorCode method of the first error in the chain having one, as exposed by smithy.APIError.
+func AWSThrottling(err error) bool {
+	code, ok := find(err, func(err error) (string, bool) {
+		if e, ok := err.(errorCoder); ok {
+			return e.ErrorCode(), true
+		}
+		return "", false
+	})
+	return ok && slices.Contains(AWSThrottlingCodes, code)
+}
+
+// GCPThrottling is a try.ErrorMatcher that match Google Cloud errors caused by throttling,
+// either having the HTTP status 429, read from the HTTPCode method of apierror.APIError
+// or the Code field of googleapi.Error, or the gRPC code RESOURCE_EXHAUSTED.
+func GCPThrottling(err error) bool {
+	code, ok := find(err, func(err error) (int, bool) {
+		if e, ok := err.(interface{ HTTPCode() int }); ok && e.HTTPCode() > 0 {
+			return e.HTTPCode(), true
+		}
+		v := reflect.Indirect(reflect.ValueOf(err))
+		if v.Kind() != reflect.Struct {
+			return 0, false
+		}
+		if f := v.FieldByName("Code"); f.IsValid() && f.CanInt() {
+			return int(f.Int()), true
+		}
+		return 0, false
+	})
+	if ok && code == http.StatusTooManyRequests {
+		return true
+	}
+	return GRPCCode(grpcResourceExhausted)(err)
+}
+
+// AzureThrottling is a try.ErrorMatcher that match Azure SDK errors having the HTTP status 429,
+// read from the StatusCode field of azcore.ResponseError.
+func AzureThrottling(err error) bool {
+	return HTTPStatus(http.StatusTooManyRequests)(err)
+}
+
+// Throttling is a try.ErrorMatcher that match every error covered by AWSThrottling, GCPThrottling and AzureThrottling.
+func Throttling(err error) bool {
+	return AWSThrottling(err) || GCPThrottling(err) || AzureThrottling(err)
+}
@@ -0,0 +1,79 @@
+package trymatch
+
+import (
+	"github.com/mawngo/go-try"
+	"reflect"
+	"slices"
+)
+
+type httpStatusCoder interface {
+	HTTPStatusCode() int
+}
+
+// HTTPStatus return a try.ErrorMatcher that match errors carrying any of the given HTTP status codes,
+// such as tryhttp.StatusError.
+// The status code is read from the HTTPStatusCode method, as exposed by the AWS SDK,
+// or from the StatusCode field, as exposed by the Azure SDK, of the first error in the chain having one.
+func HTTPStatus(codes ...int) try.ErrorMatcher {
+	return func(err error) bool {
+		code, ok := find(err, func(err error) (int, bool) {
+			if e, ok := err.(httpStatusCoder); ok {
+				return e.HTTPStatusCode(), true
+			}
+			v := reflect.Indirect(reflect.ValueOf(err))
+			if v.Kind() != reflect.Struct {
+				return 0, false
+			}
+			if f := v.FieldByName("StatusCode"); f.IsValid() && f.CanInt() {
+				return int(f.Int()), true
+			}
+			return 0, false
+		})
+		return ok && slices.Contains(codes, code)
+	}
+}
+
+// GRPCCode return a try.ErrorMatcher that match gRPC errors having any of the given status codes,
+// for example, GRPCCode(codes.Unavailable, codes.ResourceExhausted).
+// The code is read from the GRPCStatus method of the first error in the chain having one,
+// so this package does not depend on gRPC itself.
+func GRPCCode[C ~uint32](codes ...C) try.ErrorMatcher {
+	return func(err error) bool {
+		code, ok := find(err, func(err error) (C, bool) {
+			m := reflect.ValueOf(err).MethodByName("GRPCStatus")
+			if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+				return 0, false
+			}
+			c := m.Call(nil)[0].MethodByName("Code")
+			if !c.IsValid() || c.Type().NumIn() != 0 || c.Type().NumOut() != 1 || c.Type().Out(0).Kind() != reflect.Uint32 {
+				return 0, false
+			}
+			return C(c.Call(nil)[0].Uint()), true
+		})
+		return ok && slices.Contains(codes, code)
+	}
+}
+
+// find return the first value extracted from the errors in the chain of err, depth-first.
+func find[T any](err error, extract func(err error) (T, bool)) (T, bool) {
+	for err != nil {
+		if v, ok := extract(err); ok {
+			return v, true
+		}
+		switch e := err.(type) {
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, err := range e.Unwrap() {
+				if v, ok := find(err, extract); ok {
+					return v, true
+				}
+			}
+			err = nil
+		default:
+			err = nil
+		}
+	}
+	var empty T
+	return empty, false
+}
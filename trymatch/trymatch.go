@@ -0,0 +1,63 @@
+// Package trymatch provides ready-made error matchers for transient network errors.
+package trymatch
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+)
+
+// ConnectionErrs are the errors matched by ConnectionError.
+var ConnectionErrs = []error{
+	syscall.ECONNRESET,
+	syscall.ECONNREFUSED,
+	syscall.ECONNABORTED,
+	syscall.EPIPE,
+	io.ErrUnexpectedEOF,
+}
+
+// Timeout is a try.ErrorMatcher that match net.Error reporting a timeout,
+// such as dial, read and write timeouts, including os.ErrDeadlineExceeded.
+func Timeout(err error) bool {
+	var e net.Error
+	return errors.As(err, &e) && e.Timeout()
+}
+
+// ConnectionError is a try.ErrorMatcher that match errors caused by a broken or refused connection,
+// being one of ConnectionErrs (matched using errors.Is).
+func ConnectionError(err error) bool {
+	for i := range ConnectionErrs {
+		if errors.Is(err, ConnectionErrs[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// DNSTemporary is a try.ErrorMatcher that match DNS lookup errors that are temporary or timed out.
+// Errors of names that do not exist are not matched.
+func DNSTemporary(err error) bool {
+	var e *net.DNSError
+	return errors.As(err, &e) && (e.IsTemporary || e.IsTimeout) && !e.IsNotFound
+}
+
+// TLSHandshake is a try.ErrorMatcher that match failed TLS handshakes,
+// either receiving an invalid record or an alert from the peer.
+// Certificate verification errors are not matched, as retrying does not fix them.
+func TLSHandshake(err error) bool {
+	var certErr *tls.CertificateVerificationError
+	if errors.As(err, &certErr) {
+		return false
+	}
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	return errors.As(err, &recordErr) || errors.As(err, &alertErr)
+}
+
+// Transient is a try.ErrorMatcher that match every error covered by Timeout,
+// ConnectionError, DNSTemporary and TLSHandshake.
+func Transient(err error) bool {
+	return Timeout(err) || ConnectionError(err) || DNSTemporary(err) || TLSHandshake(err)
+}
@@ -0,0 +1,129 @@
+package trymatch
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/mawngo/go-try"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestMatchers(t *testing.T) {
+	timeout := &net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded}
+	assert.True(t, Timeout(fmt.Errorf("connect: %w", timeout)))
+	assert.False(t, Timeout(errors.New("timeout")))
+
+	reset := &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+	assert.True(t, ConnectionError(reset))
+	assert.True(t, ConnectionError(io.ErrUnexpectedEOF))
+	assert.False(t, ConnectionError(io.EOF))
+
+	assert.True(t, DNSTemporary(&net.DNSError{Err: "server misbehaving", Name: "example.com", IsTemporary: true}))
+	assert.False(t, DNSTemporary(&net.DNSError{Err: "no such host", Name: "example.com", IsNotFound: true}))
+
+	assert.True(t, TLSHandshake(tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}))
+	assert.True(t, TLSHandshake(fmt.Errorf("handshake: %w", tls.AlertError(40))))
+	assert.False(t, TLSHandshake(&tls.CertificateVerificationError{Err: errors.New("unknown authority")}))
+
+	assert.True(t, Transient(reset))
+	assert.False(t, Transient(errors.New("other")))
+}
+
+func TestRetryIfTransient(t *testing.T) {
+	i := 0
+	err := try.Do(func() error {
+		i++
+		if i < 3 {
+			return &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+		}
+		return context.Canceled
+	}, try.WithRetryIf(Transient), try.WithNoBackoff())
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 3, i)
+}
+
+type httpError struct {
+	StatusCode int
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("status %d", e.StatusCode)
+}
+
+type grpcCode uint32
+
+type grpcStatus struct {
+	code grpcCode
+}
+
+func (s *grpcStatus) Code() grpcCode {
+	if s == nil {
+		return 0
+	}
+	return s.code
+}
+
+type grpcError struct {
+	s *grpcStatus
+}
+
+func (e grpcError) Error() string {
+	return fmt.Sprintf("rpc error: code = %d", e.s.code)
+}
+
+func (e grpcError) GRPCStatus() *grpcStatus {
+	return e.s
+}
+
+func TestStatusMatchers(t *testing.T) {
+	assert.True(t, HTTPStatus(429, 503)(fmt.Errorf("call: %w", &httpError{StatusCode: 503})))
+	assert.False(t, HTTPStatus(429, 503)(&httpError{StatusCode: 500}))
+	assert.False(t, HTTPStatus(429)(errors.New("429")))
+
+	unavailable := grpcError{s: &grpcStatus{code: 14}}
+	assert.True(t, GRPCCode[grpcCode](8, 14)(errors.Join(errors.New("other"), unavailable)))
+	assert.False(t, GRPCCode[grpcCode](8)(unavailable))
+	assert.False(t, GRPCCode[grpcCode](8)(errors.New("other")))
+}
+
+type awsError struct {
+	code string
+}
+
+func (e *awsError) Error() string {
+	return "api error " + e.code
+}
+
+func (e *awsError) ErrorCode() string {
+	return e.code
+}
+
+type googleError struct {
+	Code    int
+	Message string
+}
+
+func (e *googleError) Error() string {
+	return fmt.Sprintf("googleapi: Error %d: %s", e.Code, e.Message)
+}
+
+func TestThrottlingMatchers(t *testing.T) {
+	assert.True(t, AWSThrottling(fmt.Errorf("operation error: %w", &awsError{code: "ThrottlingException"})))
+	assert.False(t, AWSThrottling(&awsError{code: "AccessDenied"}))
+
+	assert.True(t, GCPThrottling(&googleError{Code: 429, Message: "rateLimitExceeded"}))
+	assert.False(t, GCPThrottling(&googleError{Code: 404}))
+	assert.True(t, GCPThrottling(grpcError{s: &grpcStatus{code: 8}}))
+
+	assert.True(t, AzureThrottling(&httpError{StatusCode: 429}))
+	assert.False(t, AzureThrottling(&httpError{StatusCode: 503}))
+
+	assert.True(t, Throttling(&awsError{code: "SlowDown"}))
+	assert.False(t, Throttling(errors.New("throttling")))
+}
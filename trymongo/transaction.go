@@ -0,0 +1,31 @@
+package trymongo
+
+import (
+	"context"
+	"github.com/mawngo/go-try"
+)
+
+// RunTransaction run a transaction with retry.
+// See RunTransactionWithOptions.
+func RunTransaction(ctx context.Context, fn func(ctx context.Context) error, commit func(ctx context.Context) error, retryOptions ...try.RetryOption) error {
+	return RunTransactionWithOptions(ctx, fn, commit, try.NewOptions(retryOptions...))
+}
+
+// RunTransactionWithOptions run fn then commit with retry based on the options,
+// following the retry rules of the MongoDB drivers' WithTransaction:
+// the whole transaction is retried on TransientTransactionError, from fn or commit,
+// while only commit is retried on UnknownTransactionCommitResult.
+// The matchers of the options are replaced by these rules.
+//
+// The fn is responsible for starting the transaction, and aborting it if it fails,
+// for example, using session.StartTransaction and session.AbortTransaction of the driver.
+// The commit usually calls session.CommitTransaction.
+func RunTransactionWithOptions(ctx context.Context, fn func(ctx context.Context) error, commit func(ctx context.Context) error, options try.Options) error {
+	commitOptions := options.With(try.WithRetryIf(UnknownTransactionCommitResult))
+	return try.DoCtxWithOptions(ctx, func(ctx context.Context) error {
+		if err := fn(ctx); err != nil {
+			return err
+		}
+		return try.DoCtxWithOptions(ctx, commit, commitOptions)
+	}, options.With(try.WithRetryIf(TransientTransactionError)))
+}
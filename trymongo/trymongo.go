@@ -0,0 +1,99 @@
+// Package trymongo provides error matchers and a transaction helper for retrying MongoDB operations.
+//
+// The matchers rely on the methods exposed by the errors of the official MongoDB driver
+// (HasErrorLabel and HasErrorCode), so this package does not depend on the driver itself.
+package trymongo
+
+import (
+	"errors"
+	"github.com/mawngo/go-try"
+)
+
+const (
+	// LabelTransientTransactionError is the label of errors after which the whole transaction can be retried.
+	LabelTransientTransactionError = "TransientTransactionError"
+	// LabelUnknownTransactionCommitResult is the label of errors after which the commit can be retried.
+	LabelUnknownTransactionCommitResult = "UnknownTransactionCommitResult"
+	// LabelNetworkError is the label the driver adds to network errors.
+	LabelNetworkError = "NetworkError"
+)
+
+// NetworkErrorCodes are the server error codes caused by network or replica set state changes,
+// which are retryable according to the MongoDB retryable writes specification.
+var NetworkErrorCodes = []int{
+	6,     // HostUnreachable
+	7,     // HostNotFound
+	89,    // NetworkTimeout
+	91,    // ShutdownInProgress
+	189,   // PrimarySteppedDown
+	262,   // ExceededTimeLimit
+	9001,  // SocketException
+	10107, // NotWritablePrimary
+	11600, // InterruptedAtShutdown
+	11602, // InterruptedDueToReplStateChange
+	13435, // NotPrimaryNoSecondaryOk
+	13436, // NotPrimaryOrSecondary
+}
+
+type labeled interface {
+	HasErrorLabel(label string) bool
+}
+
+type coded interface {
+	HasErrorCode(code int) bool
+}
+
+// Label return a try.ErrorMatcher that match errors having any of the given labels.
+func Label(label string, labels ...string) try.ErrorMatcher {
+	labels = append([]string{label}, labels...)
+	return func(err error) bool {
+		var e labeled
+		if !errors.As(err, &e) {
+			return false
+		}
+		for i := range labels {
+			if e.HasErrorLabel(labels[i]) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Code return a try.ErrorMatcher that match server errors having any of the given codes.
+func Code(code int, codes ...int) try.ErrorMatcher {
+	codes = append([]int{code}, codes...)
+	return func(err error) bool {
+		var e coded
+		if !errors.As(err, &e) {
+			return false
+		}
+		for i := range codes {
+			if e.HasErrorCode(codes[i]) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// TransientTransactionError is a try.ErrorMatcher that match errors labeled TransientTransactionError.
+func TransientTransactionError(err error) bool {
+	return Label(LabelTransientTransactionError)(err)
+}
+
+// UnknownTransactionCommitResult is a try.ErrorMatcher that match errors labeled UnknownTransactionCommitResult.
+func UnknownTransactionCommitResult(err error) bool {
+	return Label(LabelUnknownTransactionCommitResult)(err)
+}
+
+// NetworkError is a try.ErrorMatcher that match network errors, either labeled NetworkError or having one of NetworkErrorCodes.
+func NetworkError(err error) bool {
+	return Label(LabelNetworkError)(err) || Code(NetworkErrorCodes[0], NetworkErrorCodes[1:]...)(err)
+}
+
+// Transient is a try.ErrorMatcher that match every error covered by TransientTransactionError,
+// UnknownTransactionCommitResult and NetworkError.
+func Transient(err error) bool {
+	return TransientTransactionError(err) || UnknownTransactionCommitResult(err) || NetworkError(err)
+}
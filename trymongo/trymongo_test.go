@@ -0,0 +1,91 @@
+package trymongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/mawngo/go-try"
+	"github.com/stretchr/testify/assert"
+	"slices"
+	"testing"
+)
+
+type serverError struct {
+	code   int
+	labels []string
+}
+
+func (e serverError) Error() string {
+	return fmt.Sprintf("server error %d", e.code)
+}
+
+func (e serverError) HasErrorLabel(label string) bool {
+	return slices.Contains(e.labels, label)
+}
+
+func (e serverError) HasErrorCode(code int) bool {
+	return e.code == code
+}
+
+func TestMatchers(t *testing.T) {
+	transient := fmt.Errorf("commit: %w", serverError{code: 112, labels: []string{LabelTransientTransactionError}})
+	assert.True(t, TransientTransactionError(transient))
+	assert.False(t, UnknownTransactionCommitResult(transient))
+	assert.True(t, Transient(transient))
+
+	steppedDown := serverError{code: 189}
+	assert.True(t, NetworkError(steppedDown))
+	assert.True(t, Code(1, 189)(steppedDown))
+	assert.False(t, Transient(errors.New("other")))
+}
+
+func TestRetryTransient(t *testing.T) {
+	i := 0
+	err := try.Do(func() error {
+		i++
+		if i < 3 {
+			return serverError{code: 11600}
+		}
+		return serverError{code: 11000}
+	}, try.WithRetryIf(Transient), try.WithNoBackoff())
+	assert.Equal(t, serverError{code: 11000}, err)
+	assert.Equal(t, 3, i)
+}
+
+func TestRunTransaction(t *testing.T) {
+	transient := serverError{code: 112, labels: []string{LabelTransientTransactionError}}
+	unknown := serverError{code: 50, labels: []string{LabelUnknownTransactionCommitResult}}
+
+	runs, commits := 0, 0
+	err := RunTransaction(context.Background(), func(_ context.Context) error {
+		runs++
+		if runs == 1 {
+			return transient
+		}
+		return nil
+	}, func(_ context.Context) error {
+		commits++
+		switch commits {
+		case 1:
+			// Retrying the commit only.
+			return unknown
+		case 2:
+			// Retrying the whole transaction.
+			return transient
+		}
+		return nil
+	}, try.WithNoBackoff())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, runs)
+	assert.Equal(t, 3, commits)
+
+	runs = 0
+	err = RunTransaction(context.Background(), func(_ context.Context) error {
+		runs++
+		return serverError{code: 11000}
+	}, func(_ context.Context) error {
+		return nil
+	}, try.WithNoBackoff())
+	assert.Equal(t, serverError{code: 11000}, err)
+	assert.Equal(t, 1, runs)
+}
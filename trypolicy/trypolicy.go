@@ -0,0 +1,173 @@
+// Package trypolicy provides a serializable description of a retry policy,
+// so retry behavior can live in configuration files and be tuned without code changes.
+//
+// A Policy can be decoded from JSON, or from YAML using a decoder that supports encoding.TextUnmarshaler,
+// then converted into try.Options:
+//
+//	{"attempts": 5, "backoff": {"type": "exponential", "initial": "200ms", "max": "10s", "jitter": "50ms"}}
+package trypolicy
+
+import (
+	"errors"
+	"fmt"
+	"github.com/mawngo/go-try"
+	"github.com/mawngo/go-try/backoff"
+	"time"
+)
+
+// ErrUnknownBackoff is returned when the backoff type of a policy is not supported.
+// The error also wraps ErrInvalidPolicy.
+var ErrUnknownBackoff = errors.New("unknown backoff type")
+
+// ErrInvalidPolicy is returned when a policy contains nonsensical values.
+// It is try.ErrInvalidPolicy, so errors of a Policy and of try.ParsePolicy can be checked the same way.
+var ErrInvalidPolicy = try.ErrInvalidPolicy
+
+// Backoff types supported by Backoff.Type.
+const (
+	// TypeNone disable backoff.
+	TypeNone = "none"
+	// TypeFixed wait Initial between retries.
+	TypeFixed = "fixed"
+	// TypeExponential multiply the wait time by Multiplier (default 2) every retry, starting from Initial, up to Max.
+	TypeExponential = "exponential"
+	// TypeIncremental add Increment to the wait time every retry, starting from Initial, up to Max.
+	TypeIncremental = "incremental"
+	// TypeDecorrelated use the decorrelated jitter algorithm, starting from Initial, up to Max.
+	// See backoff.NewDecorrelatedJitterBackoff.
+	TypeDecorrelated = "decorrelated"
+)
+
+// Duration is a time.Duration encoded as a string such as "200ms", using time.ParseDuration.
+type Duration time.Duration
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Backoff describes a backoff strategy.
+type Backoff struct {
+	// Type of the backoff, one of the Type constants.
+	Type string `json:"type" yaml:"type"`
+	// Initial is the wait time before the first retry.
+	Initial Duration `json:"initial,omitempty" yaml:"initial,omitempty"`
+	// Max is the maximum wait time, 0 means no maximum.
+	Max Duration `json:"max,omitempty" yaml:"max,omitempty"`
+	// Multiplier of TypeExponential, default to 2.
+	Multiplier float64 `json:"multiplier,omitempty" yaml:"multiplier,omitempty"`
+	// Increment of TypeIncremental.
+	Increment Duration `json:"increment,omitempty" yaml:"increment,omitempty"`
+	// Jitter is the maximum random duration added to the wait time, 0 means no jitter.
+	Jitter Duration `json:"jitter,omitempty" yaml:"jitter,omitempty"`
+}
+
+// Policy describes the retry behavior.
+// Unset fields keep the defaults of try.NewOptions.
+type Policy struct {
+	// Attempts is the maximum number of runs, 0 means unlimited.
+	Attempts *int `json:"attempts,omitempty" yaml:"attempts,omitempty"`
+	// Backoff is the backoff strategy.
+	Backoff *Backoff `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+	// MaxElapsedTime is the maximum total time spent retrying, 0 means unlimited.
+	MaxElapsedTime Duration `json:"maxElapsedTime,omitempty" yaml:"maxElapsedTime,omitempty"`
+	// RetryOnContextError retry context errors returned by the operation.
+	RetryOnContextError bool `json:"retryOnContextError,omitempty" yaml:"retryOnContextError,omitempty"`
+}
+
+// RetryOption return a try.RetryOption applying the policy.
+func (p Policy) RetryOption() (try.RetryOption, error) {
+	var retryOptions []try.RetryOption
+	if p.Attempts != nil {
+		if *p.Attempts < 0 {
+			return nil, fmt.Errorf("%w: negative attempts %d", ErrInvalidPolicy, *p.Attempts)
+		}
+		retryOptions = append(retryOptions, try.WithAttempts(*p.Attempts))
+	}
+	if p.Backoff != nil {
+		opt, err := p.Backoff.RetryOption()
+		if err != nil {
+			return nil, err
+		}
+		retryOptions = append(retryOptions, opt)
+	}
+	if p.MaxElapsedTime < 0 {
+		return nil, fmt.Errorf("%w: negative max elapsed time %s", ErrInvalidPolicy, time.Duration(p.MaxElapsedTime))
+	}
+	if p.MaxElapsedTime > 0 {
+		retryOptions = append(retryOptions, try.WithMaxElapsedTime(time.Duration(p.MaxElapsedTime)))
+	}
+	if p.RetryOnContextError {
+		retryOptions = append(retryOptions, try.WithRetryOnContextError())
+	}
+	return func(options *try.Options) {
+		for _, o := range retryOptions {
+			o(options)
+		}
+	}, nil
+}
+
+// Options return the try.Options of the policy, starting from the defaults of try.NewOptions.
+// The returned Options are validated using try.Options.Validate, like try.ParsePolicy.
+func (p Policy) Options() (try.Options, error) {
+	opt, err := p.RetryOption()
+	if err != nil {
+		return try.Options{}, err
+	}
+	options := try.NewOptions(opt)
+	if err := options.Validate(); err != nil {
+		return try.Options{}, fmt.Errorf("%w: %w", ErrInvalidPolicy, err)
+	}
+	return options, nil
+}
+
+// RetryOption return a try.RetryOption configuring the backoff strategy.
+func (b Backoff) RetryOption() (try.RetryOption, error) {
+	initial, maximum, jitter := time.Duration(b.Initial), time.Duration(b.Max), time.Duration(b.Jitter)
+	if initial < 0 || maximum < 0 || jitter < 0 || b.Increment < 0 || b.Multiplier < 0 {
+		return nil, fmt.Errorf("%w: negative backoff value", ErrInvalidPolicy)
+	}
+	if maximum > 0 && maximum < initial {
+		return nil, fmt.Errorf("%w: max backoff %s is smaller than initial backoff %s", ErrInvalidPolicy, maximum, initial)
+	}
+	if jitter > 0 && (b.Type == TypeNone || b.Type == TypeDecorrelated) {
+		return nil, fmt.Errorf("%w: jitter is not supported by backoff %q", ErrInvalidPolicy, b.Type)
+	}
+	switch b.Type {
+	case TypeNone:
+		return try.WithNoBackoff(), nil
+	case TypeFixed:
+		if jitter > 0 {
+			return try.WithBackoff(backoff.NewRandomBackoff(initial, jitter)), nil
+		}
+		return try.WithBackoff(backoff.NewFixedBackoff(initial)), nil
+	case TypeExponential:
+		multiplier := b.Multiplier
+		if multiplier == 0 {
+			multiplier = 2
+		}
+		if jitter > 0 {
+			return try.WithBackoff(backoff.NewExponentialRandomBackoff(initial, multiplier, maximum, jitter)), nil
+		}
+		return try.WithBackoff(backoff.NewExponentialBackoff(initial, multiplier, maximum)), nil
+	case TypeIncremental:
+		increment := time.Duration(b.Increment)
+		if jitter > 0 {
+			return try.WithBackoff(backoff.NewIncrementalRandomBackoff(initial, increment, maximum, jitter)), nil
+		}
+		return try.WithBackoff(backoff.NewIncrementalBackoff(initial, increment, maximum)), nil
+	case TypeDecorrelated:
+		return try.WithStatefulBackoff(backoff.NewDecorrelatedJitterBackoff(initial, maximum)), nil
+	}
+	return nil, fmt.Errorf("%w: %w: %q", ErrInvalidPolicy, ErrUnknownBackoff, b.Type)
+}
@@ -0,0 +1,82 @@
+package trypolicy
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/mawngo/go-try"
+	"github.com/mawngo/go-try/trytest"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var errFailed = errors.New("failed")
+
+func TestPolicyJSON(t *testing.T) {
+	var p Policy
+	err := json.Unmarshal([]byte(`{"attempts":4,"backoff":{"type":"exponential","initial":"200ms","max":"1s"}}`), &p)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, *p.Attempts)
+	assert.Equal(t, Duration(200*time.Millisecond), p.Backoff.Initial)
+
+	opt, err := p.RetryOption()
+	assert.NoError(t, err)
+	rec := trytest.RunAndRecord(func() error {
+		return errFailed
+	}, opt)
+	assert.ErrorIs(t, rec.Err, try.ErrRetryAttemptsExceed)
+	assert.Equal(t, []time.Duration{200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond}, rec.Delays)
+
+	data, err := json.Marshal(p)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"attempts":4,"backoff":{"type":"exponential","initial":"200ms","max":"1s"}}`, string(data))
+}
+
+func TestPolicyOptions(t *testing.T) {
+	options, err := Policy{}.Options()
+	assert.NoError(t, err)
+	assert.Equal(t, try.DefaultMaxAttempts, options.Attempts())
+	assert.True(t, options.HasBackoff())
+
+	unlimited := 0
+	options, err = Policy{
+		Attempts:            &unlimited,
+		Backoff:             &Backoff{Type: TypeNone},
+		MaxElapsedTime:      Duration(time.Minute),
+		RetryOnContextError: true,
+	}.Options()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, options.Attempts())
+	assert.False(t, options.HasBackoff())
+	assert.Equal(t, time.Minute, options.MaxElapsedTime())
+	assert.True(t, options.RetryOnContextError())
+}
+
+func TestPolicyInvalid(t *testing.T) {
+	_, err := Policy{Backoff: &Backoff{Type: "unknown"}}.Options()
+	assert.ErrorIs(t, err, ErrUnknownBackoff)
+
+	_, err = Policy{Backoff: &Backoff{Type: TypeExponential, Initial: Duration(time.Second), Max: Duration(time.Millisecond)}}.Options()
+	assert.ErrorIs(t, err, ErrInvalidPolicy)
+
+	negative := -1
+	_, err = Policy{Attempts: &negative}.Options()
+	assert.ErrorIs(t, err, ErrInvalidPolicy)
+
+	var p Policy
+	assert.Error(t, json.Unmarshal([]byte(`{"maxElapsedTime":"soon"}`), &p))
+
+	// The options are validated, and the errors match try.ErrInvalidPolicy.
+	assert.NoError(t, json.Unmarshal([]byte(`{"attempts":0,"backoff":{"type":"none"}}`), &p))
+	_, err = p.Options()
+	assert.ErrorIs(t, err, try.ErrInvalidPolicy)
+	assert.ErrorIs(t, err, try.ErrInvalidOptions)
+
+	_, err = Policy{Backoff: &Backoff{Type: "unknown"}}.Options()
+	assert.ErrorIs(t, err, try.ErrInvalidPolicy)
+
+	for _, typ := range []string{TypeNone, TypeDecorrelated} {
+		_, err = Policy{Backoff: &Backoff{Type: typ, Initial: Duration(time.Second), Jitter: Duration(time.Second)}}.Options()
+		assert.ErrorIs(t, err, ErrInvalidPolicy, typ)
+	}
+}
@@ -0,0 +1,115 @@
+// Package trysql provides retries for database/sql transactions.
+//
+// The matchers rely on the errors of the common drivers
+// (the SQLState method of the Postgres drivers and the Number field of the MySQL driver),
+// so this package does not depend on the drivers themselves.
+package trysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/mawngo/go-try"
+	"reflect"
+	"slices"
+)
+
+// PostgresRetryableCodes are the Postgres SQLSTATE codes after which the transaction can be retried.
+var PostgresRetryableCodes = []string{
+	"40001", // serialization_failure
+	"40P01", // deadlock_detected
+}
+
+// MySQLRetryableCodes are the MySQL error numbers after which the transaction can be retried.
+var MySQLRetryableCodes = []int{
+	1205, // ER_LOCK_WAIT_TIMEOUT
+	1213, // ER_LOCK_DEADLOCK
+}
+
+type sqlState interface {
+	SQLState() string
+}
+
+// PostgresCode return a try.ErrorMatcher that match Postgres errors having any of the given SQLSTATE codes.
+// Errors are matched by their SQLState method, as exposed by pgx and lib/pq.
+func PostgresCode(code string, codes ...string) try.ErrorMatcher {
+	codes = append([]string{code}, codes...)
+	return func(err error) bool {
+		var e sqlState
+		if !errors.As(err, &e) {
+			return false
+		}
+		return slices.Contains(codes, e.SQLState())
+	}
+}
+
+// MySQLCode return a try.ErrorMatcher that match MySQL errors having any of the given error numbers.
+// Errors are matched by their Number field, as exposed by go-sql-driver/mysql.
+func MySQLCode(code int, codes ...int) try.ErrorMatcher {
+	codes = append([]int{code}, codes...)
+	return func(err error) bool {
+		number, ok := mysqlNumber(err)
+		return ok && slices.Contains(codes, number)
+	}
+}
+
+// Retryable is a try.ErrorMatcher that match serialization failures and deadlocks,
+// having one of PostgresRetryableCodes or MySQLRetryableCodes.
+func Retryable(err error) bool {
+	return PostgresCode(PostgresRetryableCodes[0], PostgresRetryableCodes[1:]...)(err) ||
+		MySQLCode(MySQLRetryableCodes[0], MySQLRetryableCodes[1:]...)(err)
+}
+
+// mysqlNumber return the Number field of the first error in the chain of err having one.
+func mysqlNumber(err error) (int, bool) {
+	for err != nil {
+		v := reflect.Indirect(reflect.ValueOf(err))
+		if v.Kind() == reflect.Struct {
+			if f := v.FieldByName("Number"); f.IsValid() && f.CanUint() {
+				return int(f.Uint()), true
+			}
+		}
+		switch e := err.(type) {
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, err := range e.Unwrap() {
+				if number, ok := mysqlNumber(err); ok {
+					return number, true
+				}
+			}
+			return 0, false
+		default:
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// ExecTx run fn in a transaction with retry.
+// See ExecTxWithOptions.
+func ExecTx(ctx context.Context, db *sql.DB, fn func(ctx context.Context, tx *sql.Tx) error, retryOptions ...try.RetryOption) error {
+	return ExecTxWithOptions(ctx, db, nil, fn, try.NewOptions(retryOptions...))
+}
+
+// ExecTxWithOptions run fn in a transaction started using txOptions, and commit it, with retry based on the options.
+// Every attempt runs in a new transaction, which is rolled back if fn fails or panics.
+// Errors matched by Retryable are retried, unless a matcher is configured using try.WithRetryIf.
+// Since the whole transaction is retried, fn must not have side effects outside the transaction.
+func ExecTxWithOptions(ctx context.Context, db *sql.DB, txOptions *sql.TxOptions, fn func(ctx context.Context, tx *sql.Tx) error, options try.Options) error {
+	if !options.HasRetryIf() {
+		options = options.With(try.WithRetryIf(Retryable))
+	}
+	return try.DoCtxWithOptions(ctx, func(ctx context.Context) error {
+		tx, err := db.BeginTx(ctx, txOptions)
+		if err != nil {
+			return err
+		}
+		// Rollback is a no-op once the transaction is committed.
+		defer func() { _ = tx.Rollback() }()
+		if err := fn(ctx, tx); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}, options)
+}
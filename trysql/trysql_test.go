@@ -0,0 +1,113 @@
+package trysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/mawngo/go-try"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+)
+
+type pgError struct {
+	code string
+}
+
+func (e *pgError) Error() string {
+	return "pg error " + e.code
+}
+
+func (e *pgError) SQLState() string {
+	return e.code
+}
+
+type mysqlError struct {
+	Number  uint16
+	Message string
+}
+
+func (e *mysqlError) Error() string {
+	return fmt.Sprintf("Error %d: %s", e.Number, e.Message)
+}
+
+type fakeDriver struct {
+	commits   atomic.Int32
+	rollbacks atomic.Int32
+}
+
+func (d *fakeDriver) Open(string) (driver.Conn, error) {
+	return fakeConn{d}, nil
+}
+
+type fakeConn struct {
+	d *fakeDriver
+}
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c fakeConn) Close() error {
+	return nil
+}
+
+func (c fakeConn) Begin() (driver.Tx, error) {
+	return fakeTx(c), nil
+}
+
+type fakeTx struct {
+	d *fakeDriver
+}
+
+func (tx fakeTx) Commit() error {
+	tx.d.commits.Add(1)
+	return nil
+}
+
+func (tx fakeTx) Rollback() error {
+	tx.d.rollbacks.Add(1)
+	return nil
+}
+
+func TestMatchers(t *testing.T) {
+	assert.True(t, Retryable(fmt.Errorf("update: %w", &pgError{code: "40001"})))
+	assert.True(t, Retryable(&pgError{code: "40P01"}))
+	assert.False(t, Retryable(&pgError{code: "23505"}))
+	assert.True(t, Retryable(errors.Join(errors.New("other"), &mysqlError{Number: 1213})))
+	assert.False(t, Retryable(&mysqlError{Number: 1062}))
+	assert.True(t, MySQLCode(1062)(&mysqlError{Number: 1062}))
+	assert.False(t, Retryable(errors.New("40001")))
+}
+
+func TestExecTx(t *testing.T) {
+	d := &fakeDriver{}
+	sql.Register("trysql", d)
+	db, err := sql.Open("trysql", "")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	i := 0
+	err = ExecTx(context.Background(), db, func(_ context.Context, tx *sql.Tx) error {
+		i++
+		if i < 3 {
+			return &pgError{code: "40001"}
+		}
+		return nil
+	}, try.WithNoBackoff())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, i)
+	assert.Equal(t, int32(1), d.commits.Load())
+	assert.Equal(t, int32(2), d.rollbacks.Load())
+
+	i = 0
+	err = ExecTx(context.Background(), db, func(_ context.Context, tx *sql.Tx) error {
+		i++
+		return &pgError{code: "23505"}
+	}, try.WithNoBackoff())
+	assert.Equal(t, &pgError{code: "23505"}, err)
+	assert.Equal(t, 1, i)
+	assert.Equal(t, int32(3), d.rollbacks.Load())
+}
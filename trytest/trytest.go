@@ -0,0 +1,102 @@
+// Package trytest provides helpers to test code that retries using go-try,
+// without waiting for the backoff on the wall clock.
+package trytest
+
+import (
+	"context"
+	"github.com/mawngo/go-try"
+	"github.com/mawngo/go-try/backoff"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Clock is a fake try.Clock.
+// Sleeping advance the clock instantly instead of waiting, and record the slept duration.
+// It is safe for concurrent use.
+type Clock struct {
+	mu     sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+var _ try.Clock = (*Clock)(nil)
+
+// NewClock create a fake clock starting at the given time.
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now return the current time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Sleep advance the clock by the given duration and return immediately.
+// Return the context error without advancing if the context is already done.
+func (c *Clock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sleeps = append(c.sleeps, d)
+	if d > 0 {
+		c.now = c.now.Add(d)
+	}
+	return nil
+}
+
+// Advance move the clock forward by the given duration, without recording a sleep.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Sleeps return the durations slept so far, in order.
+func (c *Clock) Sleeps() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Duration(nil), c.sleeps...)
+}
+
+// Seed return a backoff.Option that use a random generator with the given seed,
+// so the jitter of a strategy is the same on every run.
+func Seed(seed int64) backoff.Option {
+	return backoff.WithRand(rand.New(rand.NewSource(seed)))
+}
+
+// Record is the result of RunAndRecord.
+type Record struct {
+	// Attempts is the number of times the operation was called.
+	Attempts int
+	// Errs is the error returned by each attempt, nil for a successful attempt.
+	Errs []error
+	// Delays is the backoff waited before each retry.
+	Delays []time.Duration
+	// Elapsed is the virtual time spent, including the backoff.
+	Elapsed time.Duration
+	// Err is the error returned by try.Do.
+	Err error
+}
+
+// RunAndRecord run the operation using try.Do with the given options and a fake clock,
+// and return the sequence of attempts and delays.
+// The fake clock replaces any clock configured in the options.
+func RunAndRecord(op func() error, retryOptions ...try.RetryOption) Record {
+	clock := NewClock(time.Time{})
+	var rec Record
+	retryOptions = append(retryOptions[:len(retryOptions):len(retryOptions)], try.WithClock(clock))
+	rec.Err = try.Do(func() error {
+		err := op()
+		rec.Errs = append(rec.Errs, err)
+		return err
+	}, retryOptions...)
+	rec.Attempts = len(rec.Errs)
+	rec.Delays = clock.Sleeps()
+	rec.Elapsed = clock.Now().Sub(time.Time{})
+	return rec
+}
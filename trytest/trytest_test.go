@@ -0,0 +1,61 @@
+package trytest
+
+import (
+	"context"
+	"errors"
+	"github.com/mawngo/go-try"
+	"github.com/mawngo/go-try/backoff"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var errFailed = errors.New("failed")
+
+func TestRunAndRecord(t *testing.T) {
+	i := 0
+	rec := RunAndRecord(func() error {
+		i++
+		if i < 4 {
+			return errFailed
+		}
+		return nil
+	}, try.WithBackoff(backoff.NewExponentialBackoff(time.Second, 2, time.Minute)))
+	assert.NoError(t, rec.Err)
+	assert.Equal(t, 4, rec.Attempts)
+	assert.Equal(t, []error{errFailed, errFailed, errFailed, nil}, rec.Errs)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}, rec.Delays)
+	assert.Equal(t, 7*time.Second, rec.Elapsed)
+
+	rec = RunAndRecord(func() error {
+		return errFailed
+	}, try.WithAttempts(3), try.WithFixedBackoff(time.Hour))
+	assert.ErrorIs(t, rec.Err, try.ErrRetryAttemptsExceed)
+	assert.Equal(t, 3, rec.Attempts)
+	assert.Equal(t, []time.Duration{time.Hour, time.Hour}, rec.Delays)
+}
+
+func TestSeed(t *testing.T) {
+	run := func() []time.Duration {
+		return RunAndRecord(func() error {
+			return errFailed
+		}, try.WithBackoff(backoff.NewRandomBackoff(time.Second, time.Second, Seed(42)))).Delays
+	}
+	first := run()
+	assert.Len(t, first, 4)
+	assert.Equal(t, first, run())
+}
+
+func TestClock(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := NewClock(start)
+	assert.NoError(t, clock.Sleep(context.Background(), time.Second))
+	clock.Advance(time.Minute)
+	assert.Equal(t, start.Add(time.Minute+time.Second), clock.Now())
+	assert.Equal(t, []time.Duration{time.Second}, clock.Sleeps())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, clock.Sleep(ctx, time.Second), context.Canceled)
+	assert.Equal(t, start.Add(time.Minute+time.Second), clock.Now())
+}
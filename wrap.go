@@ -0,0 +1,41 @@
+package try
+
+import (
+	"context"
+)
+
+// Wrap return a function that performs the given operation with retry.
+// The options are applied once, when wrapping.
+// See DoWithOptions.
+func Wrap(op func() error, retryOptions ...RetryOption) func() error {
+	option := NewOptions(retryOptions...)
+	return func() error {
+		return DoWithOptions(op, option)
+	}
+}
+
+// Wrap1 return a function that performs the given single-argument operation with retry,
+// passing it the context of the retry.
+// The options are applied once, when wrapping.
+// See GetCtxWithOptions.
+func Wrap1[A any, B any](op func(ctx context.Context, a A) (B, error), retryOptions ...RetryOption) func(ctx context.Context, a A) (B, error) {
+	option := NewOptions(retryOptions...)
+	return func(ctx context.Context, a A) (B, error) {
+		return GetCtxWithOptions(ctx, func(ctx context.Context) (B, error) {
+			return op(ctx, a)
+		}, option)
+	}
+}
+
+// Wrap2 return a function that performs the given two-argument operation with retry,
+// passing it the context of the retry.
+// The options are applied once, when wrapping.
+// See GetCtxWithOptions.
+func Wrap2[A any, B any, C any](op func(ctx context.Context, a A, b B) (C, error), retryOptions ...RetryOption) func(ctx context.Context, a A, b B) (C, error) {
+	option := NewOptions(retryOptions...)
+	return func(ctx context.Context, a A, b B) (C, error) {
+		return GetCtxWithOptions(ctx, func(ctx context.Context) (C, error) {
+			return op(ctx, a, b)
+		}, option)
+	}
+}